@@ -0,0 +1,108 @@
+package stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/pos_error"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"sort"
+	"strconv"
+)
+
+// FindingKind is the kind of problem Analyze found in a condition
+type FindingKind string
+
+const (
+	// AlwaysTrue sub-expressions are met by every target, such as foo | !foo
+	AlwaysTrue FindingKind = "always true"
+	// AlwaysFalse sub-expressions are met by no target, such as foo & !foo
+	AlwaysFalse FindingKind = "always false"
+	// Redundant sub-expressions can be removed without changing the condition, such as the second foo in foo & foo
+	Redundant FindingKind = "redundant"
+)
+
+// Finding is a problem Analyze found in a condition
+type Finding struct {
+	// Kind is the kind of problem
+	Kind FindingKind
+	// Start is the position in the condition where the sub-expression starts, counted in runes like pos_error.PosError
+	Start int
+	// End is the position in the condition just after the sub-expression
+	End int
+	// Text is the sub-expression as it was written
+	Text string
+}
+
+// String describes the finding, for instance: 4-11: always false: b & !b
+func (f Finding) String() string {
+	return strconv.Itoa(f.Start) + "-" + strconv.Itoa(f.End) + ": " + string(f.Kind) + ": " + f.Text
+}
+
+// Analyze finds sub-expressions of condition that are always true, always false or redundant,
+// so users can be warned before saving a condition that does not do what they meant.
+//
+// Only the outermost of nested problems is reported. Expressions are treated as independent of each other,
+// so problems that only arise because one expression contains another, such as fox & !fo, are not found.
+// Findings are ordered by their position in the condition.
+//
+// The condition must follow the syntax defined by defs; if it does not, the lexing error is returned.
+func Analyze(defs types.TokensDefinition, condition string) ([]Finding, pos_error.PosError) {
+	st, err := parseSource(defs, condition)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := map[*node]int{}
+	newBDD().build(st.root, ids)
+
+	var findings []Finding
+	report := func(kind FindingKind, n *node) {
+		s := st.spans[n]
+		findings = append(findings, Finding{Kind: kind, Start: s.start, End: s.end, Text: st.text(n)})
+	}
+
+	// the right operand of a complex operator is reported with the not keyword that inverts it
+	notSize := len([]rune(keyOf(defs, types.NOT)))
+	reportRight := func(n *node) {
+		if types.IsComplexOp(n.tok.Typ) && !n.isNegation() {
+			s := span{st.operators[n].end - notSize, st.spans[n.right].end}
+			findings = append(findings, Finding{Kind: Redundant, Start: s.start, End: s.end, Text: string(st.raw[s.start:s.end])})
+		} else {
+			report(Redundant, n.right)
+		}
+	}
+
+	var walk func(*node)
+	walk = func(n *node) {
+		switch {
+		case n.tok.Typ == types.TRUE:
+		case ids[n] == bddTrue:
+			report(AlwaysTrue, n)
+		case ids[n] == bddFalse:
+			report(AlwaysFalse, n)
+		case n.isNegation():
+			walk(n.right)
+		case !n.isLeaf():
+			// an operand that is always true or false is reported as such, rather than as redundant
+			if ids[n] == ids[n.left] && !isConstant(ids[n.right]) {
+				reportRight(n)
+				walk(n.left)
+			} else if ids[n] == ids[n.right] && !isConstant(ids[n.left]) {
+				report(Redundant, n.left)
+				walk(n.right)
+			} else {
+				walk(n.left)
+				walk(n.right)
+			}
+		}
+	}
+	walk(st.root)
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Start < findings[j].Start
+	})
+	return findings, nil
+}
+
+// isConstant returns true if the decision diagram node id is always true or always false
+func isConstant(id int) bool {
+	return id == bddTrue || id == bddFalse
+}
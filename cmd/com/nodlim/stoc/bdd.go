@@ -0,0 +1,149 @@
+package stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+)
+
+// bdd is a reduced ordered binary decision diagram, used to decide facts about conditions such as whether two are
+// equivalent. Every boolean function of the variables has exactly one node, so two conditions are equivalent exactly
+// when they build the same node.
+//
+// Each expression of a condition is a variable, so expressions are treated as independent of each other.
+type bdd struct {
+	// nodes are the decision nodes. Node 0 is false and node 1 is true.
+	nodes []bddNode
+	// unique finds the existing node for a decision, so that no two nodes are the same
+	unique map[bddNode]int
+	// vars are the variable of each expression, numbered in the order they are first seen
+	vars map[string]int
+	// cache memoises applyOp
+	cache map[bddOp]int
+}
+
+// bddNode is a decision on variable v, leading to node low if it is false and node high if it is true
+type bddNode struct {
+	v    int
+	low  int
+	high int
+}
+
+// bddOp is a memoised application of op to nodes x and y
+type bddOp struct {
+	op   types.TokenType
+	x, y int
+}
+
+const (
+	bddFalse = 0
+	bddTrue  = 1
+)
+
+func newBDD() *bdd {
+	return &bdd{
+		// the terminal nodes have a variable after every other, so they always sort last
+		nodes:  []bddNode{{v: 1 << 30}, {v: 1 << 30}},
+		unique: map[bddNode]int{},
+		vars:   map[string]int{},
+		cache:  map[bddOp]int{},
+	}
+}
+
+// variable returns the node for the expression term.
+// The empty expression is contained in every target, so it is true rather than a variable.
+func (b *bdd) variable(term string) int {
+	if term == "" {
+		return bddTrue
+	}
+
+	v, ok := b.vars[term]
+	if !ok {
+		v = len(b.vars)
+		b.vars[term] = v
+	}
+	return b.make(v, bddFalse, bddTrue)
+}
+
+// make returns the node deciding on v, reusing an existing node and skipping decisions that make no difference
+func (b *bdd) make(v int, low int, high int) int {
+	if low == high {
+		return low
+	}
+
+	key := bddNode{v: v, low: low, high: high}
+	if id, ok := b.unique[key]; ok {
+		return id
+	}
+
+	b.nodes = append(b.nodes, key)
+	b.unique[key] = len(b.nodes) - 1
+	return len(b.nodes) - 1
+}
+
+// applyOp combines nodes x and y with the operator op, one of types.AND, types.OR, types.ANDNOT or types.ORNOT
+func (b *bdd) applyOp(op types.TokenType, x int, y int) int {
+	if x <= bddTrue && y <= bddTrue {
+		left, right := x == bddTrue, y == bddTrue
+		var result bool
+		switch op {
+		case types.AND:
+			result = left && right
+		case types.OR:
+			result = left || right
+		case types.ANDNOT:
+			result = left && !right
+		default: // types.ORNOT
+			result = left || !right
+		}
+		if result {
+			return bddTrue
+		}
+		return bddFalse
+	}
+
+	key := bddOp{op: op, x: x, y: y}
+	if id, ok := b.cache[key]; ok {
+		return id
+	}
+
+	nx, ny := b.nodes[x], b.nodes[y]
+	v := nx.v
+	if ny.v < v {
+		v = ny.v
+	}
+
+	xLow, xHigh := x, x
+	if nx.v == v {
+		xLow, xHigh = nx.low, nx.high
+	}
+	yLow, yHigh := y, y
+	if ny.v == v {
+		yLow, yHigh = ny.low, ny.high
+	}
+
+	id := b.make(v, b.applyOp(op, xLow, yLow), b.applyOp(op, xHigh, yHigh))
+	b.cache[key] = id
+	return id
+}
+
+// not returns the node for the inversion of x
+func (b *bdd) not(x int) int {
+	return b.applyOp(types.ANDNOT, bddTrue, x)
+}
+
+// build returns the node for the tree n, and records the node of every sub-tree in ids if it is not nil
+func (b *bdd) build(n *node, ids map[*node]int) int {
+	var id int
+	switch n.tok.Typ {
+	case types.TRUE:
+		id = bddTrue
+	case types.EXP:
+		id = b.variable(n.tok.Exp)
+	default:
+		id = b.applyOp(n.tok.Typ, b.build(n.left, ids), b.build(n.right, ids))
+	}
+
+	if ids != nil {
+		ids[n] = id
+	}
+	return id
+}
@@ -0,0 +1,44 @@
+package stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/pos_error"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"strings"
+	"unsafe"
+)
+
+// SearchBytes is the []byte variant of SearchString. The target is searched in place, without being copied.
+func SearchBytes(command string, target []byte) (bool, pos_error.PosError) {
+	return SearchBytesCustom(types.DefaultTokensDefinition, command, target)
+}
+
+// SearchBytesCustom is the []byte variant of SearchStringCustom. The target is searched in place, without being copied.
+func SearchBytesCustom(defs types.TokensDefinition, command string, target []byte) (bool, pos_error.PosError) {
+	preparedTokens, err := LexIntoTokens(defs, command)
+
+	if err == nil {
+		return SearchTokensBytes(preparedTokens, target), err
+	}
+
+	return false, err
+}
+
+// SearchTokensBytes is the []byte variant of SearchTokens. The target is searched in place, without being copied.
+func SearchTokensBytes(preparation PreparedTokens, target []byte) bool {
+	return SearchPostfixTokensBytes(preparation, target)
+}
+
+// SearchPostfixTokensBytes is the []byte variant of SearchPostfixTokens.
+// The target is searched in place, without being copied, and typical conditions are evaluated without allocating.
+func SearchPostfixTokensBytes(search []types.Token, target []byte) bool {
+	view := bytesView(target)
+	return evaluatePostfix(search, func(exp string) bool {
+		return strings.Contains(view, exp)
+	})
+}
+
+// bytesView returns a string sharing memory with b, rather than a copy of b.
+// The string is only valid while b is unmodified, so it must never outlive the search it was made for.
+func bytesView(b []byte) string {
+	return *(*string)(unsafe.Pointer(&b))
+}
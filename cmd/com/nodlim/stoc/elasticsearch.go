@@ -0,0 +1,82 @@
+package stoc
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ToElasticsearch renders pre-prepared tokens as an Elasticsearch or OpenSearch bool query on field, in the query DSL.
+// For instance, lazy & !(fox | dog) on the field body is:
+//
+//	{"bool": {
+//	  "must": [{"match_phrase": {"body": "lazy"}}],
+//	  "must_not": [{"bool": {
+//	    "should": [{"match_phrase": {"body": "fox"}}, {"match_phrase": {"body": "dog"}}],
+//	    "minimum_should_match": 1
+//	  }}]
+//	}}
+//
+// Conjunctions become must clauses, with their inverted operands in must_not, and disjunctions become should clauses.
+// Every expression, quoted or not, is a match_phrase. Elasticsearch matches phrases by the words the field was
+// analysed into rather than by text the field contains, so la does not match lazy as it would when searching.
+//
+// The result can be sent as the query of a search request, or embedded in a larger query.
+// An error is returned if there is no field, or if the tokens are not a valid postfix condition.
+func ToElasticsearch(preparation PreparedTokens, field string) (json.RawMessage, error) {
+	if field == "" {
+		return nil, errors.New("cannot render Elasticsearch query, there is no field to search")
+	}
+
+	tree, err := buildTree(preparation)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(elasticsearchQuery(toExpr(tree), field))
+}
+
+// esObject is a JSON object of the query DSL
+type esObject = map[string]any
+
+// elasticsearchQuery converts e into a query on field
+func elasticsearchQuery(e *expr, field string) esObject {
+	switch e.kind {
+	case termExpr:
+		if e.term == "" {
+			// the empty expression is in every target
+			return esObject{"match_all": esObject{}}
+		}
+		return esObject{"match_phrase": esObject{field: e.term}}
+	case trueExpr:
+		return esObject{"match_all": esObject{}}
+	case falseExpr:
+		return esObject{"match_none": esObject{}}
+	case notExpr:
+		return esObject{"bool": esObject{"must_not": []esObject{elasticsearchQuery(e.args[0], field)}}}
+	case orExpr:
+		should := make([]esObject, len(e.args))
+		for i, arg := range e.args {
+			should[i] = elasticsearchQuery(arg, field)
+		}
+		return esObject{"bool": esObject{"should": should, "minimum_should_match": 1}}
+	}
+
+	// andExpr
+	var must, mustNot []esObject
+	for _, arg := range e.args {
+		if arg.kind == notExpr {
+			mustNot = append(mustNot, elasticsearchQuery(arg.args[0], field))
+		} else {
+			must = append(must, elasticsearchQuery(arg, field))
+		}
+	}
+
+	clauses := esObject{}
+	if len(must) > 0 {
+		clauses["must"] = must
+	}
+	if len(mustNot) > 0 {
+		clauses["must_not"] = mustNot
+	}
+	return esObject{"bool": clauses}
+}
@@ -0,0 +1,199 @@
+package stoc
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"strconv"
+	"unicode/utf8"
+)
+
+// encodingVersion is the version of the JSON and binary encodings of PreparedTokens.
+// It changes whenever an encoding changes in a way older versions of stoc cannot read.
+const encodingVersion = 1
+
+// binaryMagic starts every binary encoding of PreparedTokens
+const binaryMagic = "STOC"
+
+// tokenCodes are the codes of each token type in the binary encoding. Codes must never be reused.
+var tokenCodes = map[types.TokenType]byte{
+	types.EXP:    1,
+	types.TRUE:   2,
+	types.AND:    3,
+	types.OR:     4,
+	types.ANDNOT: 5,
+	types.ORNOT:  6,
+}
+
+// ErrInvalidEncoding is returned, wrapped with more detail, when encoded PreparedTokens cannot be decoded
+var ErrInvalidEncoding = errors.New("invalid encoding of prepared tokens")
+
+// encodingError wraps ErrInvalidEncoding with a description of the problem
+type encodingError struct {
+	detail string
+}
+
+func (err *encodingError) Error() string {
+	return ErrInvalidEncoding.Error() + ", " + err.detail
+}
+
+func (err *encodingError) Unwrap() error {
+	return ErrInvalidEncoding
+}
+
+// jsonTokens is the JSON encoding of PreparedTokens
+type jsonTokens struct {
+	Version int         `json:"version"`
+	Tokens  []jsonToken `json:"tokens"`
+}
+
+// jsonToken is the JSON encoding of a types.Token
+type jsonToken struct {
+	Type types.TokenType `json:"type"`
+	Exp  string          `json:"exp"`
+}
+
+// MarshalJSON encodes the tokens as a versioned JSON object, for example:
+//
+//	{"version":1,"tokens":[{"type":"EXPRESSION","exp":"foo"},{"type":"EXPRESSION","exp":"bar"},{"type":"AND","exp":"&"}]}
+func (pt PreparedTokens) MarshalJSON() ([]byte, error) {
+	encoded := jsonTokens{Version: encodingVersion, Tokens: make([]jsonToken, len(pt))}
+	for i, tok := range pt {
+		encoded.Tokens[i] = jsonToken{Type: tok.Typ, Exp: tok.Exp}
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON decodes tokens encoded by MarshalJSON.
+//
+// The decoded tokens are validated, so that an error is returned rather than producing tokens that cannot be searched.
+// Errors from validation wrap ErrInvalidEncoding.
+func (pt *PreparedTokens) UnmarshalJSON(data []byte) error {
+	var encoded jsonTokens
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+
+	if encoded.Version != encodingVersion {
+		return &encodingError{"unsupported version " + strconv.Itoa(encoded.Version)}
+	}
+
+	decoded := make(PreparedTokens, len(encoded.Tokens))
+	for i, tok := range encoded.Tokens {
+		decoded[i] = types.Token{Typ: tok.Type, Exp: tok.Exp}
+	}
+
+	if err := ValidateTokens(decoded); err != nil {
+		return err
+	}
+
+	*pt = decoded
+	return nil
+}
+
+// MarshalBinary encodes the tokens in a compact binary form.
+//
+// The encoding is the magic bytes "STOC", a version byte, the number of tokens as a uvarint,
+// and then for each token a type code byte followed by the length of its text as a uvarint and the text itself.
+func (pt PreparedTokens) MarshalBinary() ([]byte, error) {
+	data := append([]byte(binaryMagic), encodingVersion)
+	data = appendUvarint(data, uint64(len(pt)))
+	for i, tok := range pt {
+		code, ok := tokenCodes[tok.Typ]
+		if !ok {
+			return nil, &encodingError{"token " + strconv.Itoa(i) + " has unknown type " + string(tok.Typ)}
+		}
+		data = append(data, code)
+		data = appendUvarint(data, uint64(len(tok.Exp)))
+		data = append(data, tok.Exp...)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes tokens encoded by MarshalBinary.
+//
+// The decoded tokens are validated, so that an error is returned rather than producing tokens that cannot be searched.
+// All errors wrap ErrInvalidEncoding.
+func (pt *PreparedTokens) UnmarshalBinary(data []byte) error {
+	if len(data) < len(binaryMagic)+1 || string(data[:len(binaryMagic)]) != binaryMagic {
+		return &encodingError{"missing magic bytes"}
+	}
+
+	if version := data[len(binaryMagic)]; version != encodingVersion {
+		return &encodingError{"unsupported version " + strconv.Itoa(int(version))}
+	}
+
+	data = data[len(binaryMagic)+1:]
+	count, n := binary.Uvarint(data)
+	// every token needs at least two bytes, which also bounds the allocation below for corrupted counts
+	if n <= 0 || count > uint64(len(data)-n)/2 {
+		return &encodingError{"invalid token count"}
+	}
+	data = data[n:]
+
+	codeTypes := make(map[byte]types.TokenType, len(tokenCodes))
+	for typ, code := range tokenCodes {
+		codeTypes[code] = typ
+	}
+
+	decoded := make(PreparedTokens, count)
+	for i := range decoded {
+		typ, ok := codeTypes[data[0]]
+		if !ok {
+			return &encodingError{"token " + strconv.Itoa(i) + " has unknown type code " + strconv.Itoa(int(data[0]))}
+		}
+
+		size, n := binary.Uvarint(data[1:])
+		if n <= 0 || size > uint64(len(data)-1-n) {
+			return &encodingError{"token " + strconv.Itoa(i) + " has an invalid length"}
+		}
+		data = data[1+n:]
+
+		if !utf8.Valid(data[:size]) {
+			return &encodingError{"token " + strconv.Itoa(i) + " is not valid UTF-8"}
+		}
+		decoded[i] = types.Token{Typ: typ, Exp: string(data[:size])}
+		data = data[size:]
+
+		if i < len(decoded)-1 && len(data) < 2 {
+			return &encodingError{"unexpected end of data"}
+		}
+	}
+
+	if len(data) != 0 {
+		return &encodingError{"unexpected data after the last token"}
+	}
+
+	if err := ValidateTokens(decoded); err != nil {
+		return err
+	}
+
+	*pt = decoded
+	return nil
+}
+
+// ValidateTokens checks that tokens are a valid postfix condition that can be searched.
+// Tokens produced by LexIntoTokens are always valid; tokens from other sources, such as a decoded payload, may not be.
+//
+// The error wraps ErrInvalidEncoding.
+func ValidateTokens(tokens []types.Token) error {
+	for i, tok := range tokens {
+		if _, ok := tokenCodes[tok.Typ]; !ok {
+			return &encodingError{"token " + strconv.Itoa(i) + " has unknown type " + string(tok.Typ)}
+		}
+	}
+
+	if _, err := buildTree(tokens); err != nil {
+		return &encodingError{err.Error() + " at token " + strconv.Itoa(err.GetPos())}
+	}
+
+	return nil
+}
+
+// appendUvarint appends the uvarint encoding of x to data
+func appendUvarint(data []byte, x uint64) []byte {
+	var buffer [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buffer[:], x)
+	return append(data, buffer[:n]...)
+}
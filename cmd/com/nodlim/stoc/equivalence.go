@@ -0,0 +1,54 @@
+package stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+)
+
+// Equivalent reports whether the pre-prepared tokens a and b are met by exactly the same targets, such as
+// foo & (bar | baz) and (foo & bar) | (foo & baz), so that saved conditions which only differ in how they are
+// written can be de-duplicated.
+//
+// Each expression is treated as an independent proposition. Expressions that contain one another are not
+// independent when searching though: any target containing "foxes" also contains "fox", so fox | foxes is met by
+// the same targets as fox, but is not reported as equivalent to it. Equivalent therefore never reports two
+// conditions as equivalent when they are not, but may miss equivalences that depend on overlapping expressions.
+//
+// An error is returned if either of the tokens are not a valid postfix condition.
+func Equivalent(a PreparedTokens, b PreparedTokens) (bool, error) {
+	x, y, _, err := buildPair(a, b)
+	if err != nil {
+		return false, err
+	}
+	return x == y, nil
+}
+
+// Subsumes reports whether every target that meets the pre-prepared tokens specific also meets general,
+// such as foo | bar subsuming foo & baz. A condition subsumes itself and any condition equivalent to it,
+// so a saved condition that is subsumed by another is only worth keeping if it is used on its own.
+//
+// Expressions are treated as independent propositions, with the same caveat about overlapping expressions as
+// Equivalent: fox subsumes foxes when searching, but that is not reported.
+//
+// An error is returned if either of the tokens are not a valid postfix condition.
+func Subsumes(general PreparedTokens, specific PreparedTokens) (bool, error) {
+	g, s, decisions, err := buildPair(general, specific)
+	if err != nil {
+		return false, err
+	}
+	return decisions.applyOp(types.ANDNOT, s, g) == bddFalse, nil
+}
+
+// buildPair builds the nodes of a and b in the same diagram, so their variables are shared
+func buildPair(a PreparedTokens, b PreparedTokens) (int, int, *bdd, error) {
+	treeA, err := buildTree(a)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	treeB, err := buildTree(b)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	decisions := newBDD()
+	return decisions.build(treeA, nil), decisions.build(treeB, nil), decisions, nil
+}
@@ -0,0 +1,75 @@
+package stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/pos_error"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Evaluator is a condition compiled for repeated searches.
+//
+// Unlike SearchPostfixTokens, which checks every expression, an Evaluator stops as soon as the result is known,
+// for instance when the left side of a conjunction is false.
+// The operands of conjunctions and disjunctions are reordered so that cheap and selective operands are checked first:
+//   - operands with fewer expressions come before operands with more
+//   - for conjunctions, longer expressions come first, as they are less likely to be found and so decide the result
+//   - for disjunctions, shorter expressions come first, as they are more likely to be found and so decide the result
+//
+// The reordering never changes the result, since conjunction and disjunction are commutative.
+type Evaluator struct {
+	root *expr
+}
+
+// NewEvaluator compiles pre-prepared tokens into an Evaluator.
+//
+// An error is returned if the tokens are not a valid postfix condition.
+func NewEvaluator(preparation PreparedTokens) (*Evaluator, pos_error.PosError) {
+	tree, err := buildTree(preparation)
+	if err != nil {
+		return nil, err
+	}
+
+	root := toExpr(tree)
+	orderByCost(root)
+	return &Evaluator{root: root}, nil
+}
+
+// Search searches target with the compiled condition. The result is always the same as SearchTokens.
+func (ev *Evaluator) Search(target string) bool {
+	return ev.root.eval(func(term string) bool {
+		return strings.Contains(target, term)
+	})
+}
+
+// orderByCost reorders the operands of every conjunction and disjunction in e, and returns the cost of e.
+// The cost of an expression is the number of terms that may have to be searched for to evaluate it.
+func orderByCost(e *expr) int {
+	switch e.kind {
+	case termExpr:
+		return 1
+	case trueExpr, falseExpr:
+		return 0
+	}
+
+	costs := make(map[*expr]int, len(e.args))
+	total := 0
+	for _, arg := range e.args {
+		costs[arg] = orderByCost(arg)
+		total += costs[arg]
+	}
+
+	sort.SliceStable(e.args, func(i, j int) bool {
+		a, b := e.args[i], e.args[j]
+		if costs[a] != costs[b] {
+			return costs[a] < costs[b]
+		} else if a.kind != termExpr || b.kind != termExpr {
+			return false
+		} else if e.kind == andExpr {
+			return utf8.RuneCountInString(a.term) > utf8.RuneCountInString(b.term)
+		}
+		return utf8.RuneCountInString(a.term) < utf8.RuneCountInString(b.term)
+	})
+
+	return total
+}
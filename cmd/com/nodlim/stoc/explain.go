@@ -0,0 +1,159 @@
+package stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/pos_error"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"strconv"
+	"strings"
+)
+
+// Explanation is an evaluated condition in tree form, recording the result of every sub-expression.
+// It answers why a condition did or did not match a target.
+type Explanation struct {
+	// Token is the operator, expression or types.TRUE token of the sub-expression
+	Token types.Token
+	// Result is whether the sub-expression was met
+	Result bool
+	// Hits are the byte offsets in the target where an expression was found. Nil for operators.
+	Hits []int
+	// Left is the left operand of an operator, nil for expressions
+	Left *Explanation
+	// Right is the right operand of an operator, nil for expressions
+	Right *Explanation
+}
+
+// ExplainString explains the result of searching through target based on the command arg.
+// The command string must be a valid condition following the syntax of types.DefaultTokensDefinition.
+func ExplainString(command string, target string) (*Explanation, pos_error.PosError) {
+	preparedTokens, err := LexIntoTokens(types.DefaultTokensDefinition, command)
+
+	if err == nil {
+		return Explain(preparedTokens, target)
+	}
+
+	return nil, err
+}
+
+// Explain evaluates pre-prepared tokens against target, keeping the result of every sub-expression
+// and where each expression was found.
+//
+// An error is returned if the tokens are not a valid postfix condition.
+func Explain(preparation PreparedTokens, target string) (*Explanation, pos_error.PosError) {
+	tree, err := buildTree(preparation)
+	if err != nil {
+		return nil, err
+	}
+
+	return explainNode(tree, target), nil
+}
+
+// explainNode evaluates n against target, and all the sub-expressions of n
+func explainNode(n *node, target string) *Explanation {
+	e := &Explanation{Token: n.tok}
+
+	switch n.tok.Typ {
+	case types.TRUE:
+		e.Result = true
+	case types.EXP:
+		e.Hits = findAll(target, n.tok.Exp)
+		e.Result = len(e.Hits) > 0
+	default:
+		e.Left = explainNode(n.left, target)
+		e.Right = explainNode(n.right, target)
+		switch n.tok.Typ {
+		case types.AND:
+			e.Result = e.Left.Result && e.Right.Result
+		case types.OR:
+			e.Result = e.Left.Result || e.Right.Result
+		case types.ANDNOT:
+			e.Result = e.Left.Result && !e.Right.Result
+		case types.ORNOT:
+			e.Result = e.Left.Result || !e.Right.Result
+		}
+	}
+
+	return e
+}
+
+// findAll returns the byte offsets of every non-overlapping occurrence of exp in target
+func findAll(target string, exp string) []int {
+	var hits []int
+	for offset := 0; offset <= len(target); {
+		i := strings.Index(target[offset:], exp)
+		if i < 0 {
+			break
+		}
+		hits = append(hits, offset+i)
+		if exp == "" {
+			break
+		}
+		offset += i + len(exp)
+	}
+	return hits
+}
+
+// String renders the explained condition with the result of each sub-expression in square brackets,
+// and the offsets of each expression that was found after an @.
+//
+// For example: (("lazy"[true @4] & !"dog"[false])[true] | "cat"[false])[true]
+func (e *Explanation) String() string {
+	var sb strings.Builder
+	e.render(&sb)
+	return sb.String()
+}
+
+// render writes the explanation to sb. See Explanation.String.
+func (e *Explanation) render(sb *strings.Builder) {
+	switch {
+	case e.Left == nil || e.Right == nil:
+		if e.Token.Typ == types.TRUE {
+			sb.WriteString("true")
+		} else {
+			sb.WriteString(quoteTerm(e.Token.Exp))
+		}
+	case e.Left.Token.Typ == types.TRUE && e.Token.Typ == types.ANDNOT:
+		sb.WriteString("!")
+		e.Right.render(sb)
+	default:
+		sb.WriteString("(")
+		e.Left.render(sb)
+		sb.WriteString(" " + operatorText(e.Token) + " ")
+		e.Right.render(sb)
+		sb.WriteString(")")
+	}
+
+	sb.WriteString("[" + strconv.FormatBool(e.Result))
+	for i, hit := range e.Hits {
+		if i == 0 {
+			sb.WriteString(" @")
+		} else {
+			sb.WriteString(",")
+		}
+		sb.WriteString(strconv.Itoa(hit))
+	}
+	sb.WriteString("]")
+}
+
+// quoteTerm surrounds exp with double quotes, or single quotes if exp contains a double quote
+func quoteTerm(exp string) string {
+	if strings.Contains(exp, "\"") {
+		return "'" + exp + "'"
+	}
+	return "\"" + exp + "\""
+}
+
+// defaultOperatorText are the operators of types.DefaultTokensDefinition, used for operator tokens without any text
+var defaultOperatorText = map[types.TokenType]string{
+	types.AND:    "&",
+	types.OR:     "|",
+	types.ANDNOT: "& !",
+	types.ORNOT:  "| !",
+}
+
+// operatorText gets the text of an operator token as it was written, with any whitespace collapsed
+func operatorText(tok types.Token) string {
+	if text := strings.Join(strings.Fields(tok.Exp), " "); text != "" {
+		return text
+	}
+	return defaultOperatorText[tok.Typ]
+}
@@ -0,0 +1,61 @@
+package stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/pos_error"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"os"
+	"strconv"
+)
+
+// ConditionFileError is an error in a condition read from a file, with the line and column where it occurred
+type ConditionFileError struct {
+	// Path is the file the condition was read from
+	Path string
+	// Line and Column are where the error occurred, from 1, with columns counted in runes
+	Line   int
+	Column int
+	// Err is the error, positioned in the condition as a whole
+	Err pos_error.PosError
+}
+
+func (err *ConditionFileError) Error() string {
+	return err.Path + ":" + strconv.Itoa(err.Line) + ":" + strconv.Itoa(err.Column) + ": " + err.Err.Error()
+}
+
+func (err *ConditionFileError) Unwrap() error {
+	return err.Err
+}
+
+// LexFileIntoTokens reads a condition from the file at path, and produces postfix tokens from it as LexIntoTokens does.
+// Filters kept in files can be written over many lines and explained with comments, if defs enables the COMMENT option
+// of the stoc types package.
+//
+// An error reading the file is returned as it is, and an invalid condition as a *ConditionFileError.
+func LexFileIntoTokens(defs types.TokensDefinition, path string) (PreparedTokens, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	condition := string(data)
+	preparedTokens, lexErr := LexIntoTokens(defs, condition)
+	if lexErr != nil {
+		line, column := lineAndColumn([]rune(condition), lexErr.GetPos())
+		return nil, &ConditionFileError{Path: path, Line: line, Column: column, Err: lexErr}
+	}
+	return preparedTokens, nil
+}
+
+// lineAndColumn finds the line and column of the position pos in raw, both from 1
+func lineAndColumn(raw []rune, pos int) (int, int) {
+	line, column := 1, 1
+	for i := 0; i < pos && i < len(raw); i++ {
+		if raw[i] == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return line, column
+}
@@ -0,0 +1,227 @@
+package stoc
+
+import (
+	"errors"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/lexer"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	stoctypes "github.com/kranzuft/stoc/cmd/com/nodlim/stoc/types"
+	"strings"
+	"unicode"
+)
+
+// Format renders pre-prepared tokens as a condition in the syntax defined by defs.
+//
+// The condition uses as few brackets as the grammar allows, quotes only the expressions that need quotes,
+// and separates operators with single spaces. Lexing the result with defs produces an equivalent condition,
+// so Format can show users a normalised form of what they typed.
+//
+// An error is returned if the tokens are not a valid postfix condition, if defs does not define the keywords needed,
+// or if an expression contains both quote keywords and so cannot be written in the syntax without escape sequences.
+//
+// Format needs the keywords of defs, which the lexer does not make available. A syntax other than
+// types.DefaultTokensDefinition must be defined with a Definition of the stoc types package, rather than a
+// TokensDefinition of the lexer, or Format returns an error saying its keywords are not known. Searching does not
+// need the keywords, so a syntax only used to search can still be defined either way.
+func Format(defs types.TokensDefinition, preparation PreparedTokens) (string, error) {
+	tree, err := buildTree(preparation)
+	if err != nil {
+		return "", err
+	}
+
+	f, fErr := newFormatter(defs)
+	if fErr != nil {
+		return "", fErr
+	}
+
+	var sb strings.Builder
+	if fErr = f.condition(&sb, tree); fErr != nil {
+		return "", fErr
+	}
+	return sb.String(), nil
+}
+
+// formatter renders trees as conditions in the syntax of defs
+type formatter struct {
+	// lex recognises the keywords of the syntax as the lexer does
+	lex lexer.Definitions
+	// implicitAnd is whether whitespace separates expressions, see types.IMPLICIT_AND of stoc
+	implicitAnd bool
+	// escapes is whether expressions can have escape sequences, see types.ESCAPE of stoc
+	escapes bool
+	// comment starts a comment, if comments are enabled, see types.COMMENT of stoc
+	comment []rune
+	// and, or, not, lbr, rbr, dquote and squote are the keywords of defs
+	and, or, not, lbr, rbr, dquote, squote string
+}
+
+// newFormatter prepares a formatter for defs, checking defs defines the keywords needed to render a condition
+func newFormatter(defs types.TokensDefinition) (*formatter, error) {
+	f := &formatter{
+		lex:         definitions(defs),
+		implicitAnd: hasOption(defs, stoctypes.IMPLICIT_AND),
+		escapes:     hasOption(defs, stoctypes.ESCAPE),
+		comment:     commentKey(defs),
+		and:         keyOf(defs, types.AND),
+		or:          keyOf(defs, types.OR),
+		not:         keyOf(defs, types.NOT),
+		lbr:         keyOf(defs, types.LBR),
+		rbr:         keyOf(defs, types.RBR),
+		dquote:      keyOf(defs, types.DQUOTE),
+		squote:      keyOf(defs, types.SQUOTE),
+	}
+
+	for typ, key := range map[types.TokenType]string{types.AND: f.and, types.OR: f.or, types.NOT: f.not, types.LBR: f.lbr, types.RBR: f.rbr} {
+		if _, ok := defs[typ]; ok && key == "" {
+			return nil, errors.New("cannot format, the keyword for " + string(typ) + " is not known, define the syntax with a types.Definition")
+		}
+		if key == "" {
+			return nil, errors.New("cannot format, the syntax does not define a keyword for " + string(typ))
+		}
+	}
+
+	if f.dquote == "" && f.squote == "" {
+		return nil, errors.New("cannot format, the syntax does not define a keyword for quotes")
+	}
+
+	return f, nil
+}
+
+// condition writes the condition n
+func (f *formatter) condition(sb *strings.Builder, n *node) error {
+	switch {
+	case n.tok.Typ == types.EXP:
+		return f.expression(sb, n.tok.Exp)
+	case n.isLeaf():
+		// a lone types.TRUE has no keyword, but the empty expression is found in every target
+		return f.expression(sb, "")
+	case n.isNegation():
+		sb.WriteString(f.not)
+		return f.operand(sb, n.right)
+	}
+
+	if err := f.condition(sb, n.left); err != nil {
+		return err
+	}
+
+	right := n.right
+	switch n.tok.Typ {
+	case types.AND, types.OR:
+		// conjunction or disjunction with a negation is the same as the complex operator on what is negated
+		if right.isNegation() {
+			f.operator(sb, n.tok.Typ)
+			sb.WriteString(" " + f.not)
+			return f.operand(sb, right.right)
+		}
+		f.operator(sb, n.tok.Typ)
+		sb.WriteString(" ")
+	case types.ANDNOT, types.ORNOT:
+		f.operator(sb, n.tok.Typ)
+		sb.WriteString(" " + f.not)
+		return f.operand(sb, right)
+	}
+
+	// operators are left associative with equal precedence, so only a right operand ever needs brackets
+	if right.isLeaf() {
+		return f.condition(sb, right)
+	}
+	return f.bracketed(sb, right)
+}
+
+// operand writes n as the operand of a not keyword, separated by a space if the keyword is a word
+func (f *formatter) operand(sb *strings.Builder, n *node) error {
+	if endsInWordRune(f.not) {
+		sb.WriteString(" ")
+	}
+	if n.isLeaf() {
+		return f.condition(sb, n)
+	}
+	return f.bracketed(sb, n)
+}
+
+// bracketed writes n surrounded by brackets
+func (f *formatter) bracketed(sb *strings.Builder, n *node) error {
+	sb.WriteString(f.lbr)
+	if err := f.condition(sb, n); err != nil {
+		return err
+	}
+	sb.WriteString(f.rbr)
+	return nil
+}
+
+// operator writes the conjunction or disjunction of typ, surrounded by spaces, without the trailing space
+func (f *formatter) operator(sb *strings.Builder, typ types.TokenType) {
+	if typ == types.AND || typ == types.ANDNOT {
+		sb.WriteString(" " + f.and)
+	} else {
+		sb.WriteString(" " + f.or)
+	}
+}
+
+// expression writes exp, quoting it only if needed. If the syntax has escape sequences, backslashes are escaped,
+// and so are quotes if exp contains every quote keyword.
+func (f *formatter) expression(sb *strings.Builder, exp string) error {
+	if f.escapes {
+		exp = strings.ReplaceAll(exp, string(escapeRune), string([]rune{escapeRune, escapeRune}))
+	}
+
+	if f.isBare(exp) {
+		sb.WriteString(exp)
+		return nil
+	}
+
+	for _, quote := range []string{f.dquote, f.squote} {
+		if quote != "" && !strings.Contains(exp, quote) {
+			sb.WriteString(quote + exp + quote)
+			return nil
+		}
+	}
+
+	if f.escapes {
+		quote := f.dquote
+		if quote == "" {
+			quote = f.squote
+		}
+		sb.WriteString(quote + strings.ReplaceAll(exp, quote, string(escapeRune)+quote) + quote)
+		return nil
+	}
+
+	return errors.New("cannot format, the expression " + exp + " contains every quote keyword of the syntax")
+}
+
+// isBare returns true if exp can be written without quotes and is lexed back as the same expression
+func (f *formatter) isBare(exp string) bool {
+	raw := []rune(exp + " ")
+	last := len(raw) - 2
+	if last < 0 || types.IsWhitespace(raw, 0) || types.IsWhitespace(raw, last) || f.lex.IsQuote(raw, 0) {
+		return false
+	}
+	// comments would start inside the expression, and its line breaks would be folded
+	if f.comment != nil && (strings.Contains(exp, string(f.comment)) || strings.ContainsRune(exp, '\n')) {
+		return false
+	}
+
+	for i := 0; i <= last; i++ {
+		if f.lex.IsKeyword(raw, i) || (f.implicitAnd && types.IsWhitespace(raw, i)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// keyOf gets the keyword defs defines for typ, or an empty string if typ is not defined or its keyword is not known.
+// See stoctypes.Definition.
+func keyOf(defs types.TokensDefinition, typ types.TokenType) string {
+	key, _ := stoctypes.Key(defs, typ)
+	return key
+}
+
+// endsInWordRune returns true if the last rune of key is a letter or digit, so key needs a space before what follows
+func endsInWordRune(key string) bool {
+	runes := []rune(key)
+	return len(runes) > 0 && isWordRune(runes[len(runes)-1])
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,234 @@
+package stoc
+
+import (
+	"context"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"strconv"
+	"strings"
+)
+
+// Limits bounds the work a search can do, for when conditions or targets come from untrusted users.
+// A zero or negative field means there is no limit.
+type Limits struct {
+	// MaxTargetSize is the maximum size of the target in bytes
+	MaxTargetSize int
+	// MaxTerms is the maximum number of expressions in the condition
+	MaxTerms int
+	// MaxDepth is the maximum nesting depth of the condition.
+	// A single expression has a depth of 1, and each conjunction or disjunction adds 1 to the depth of its operands.
+	// Chains of the same operator, such as a | b | c, are one level.
+	MaxDepth int
+}
+
+// LimitKind names the limit of Limits that was exceeded
+type LimitKind string
+
+const (
+	TargetSizeLimit LimitKind = "target size"
+	TermsLimit      LimitKind = "number of terms"
+	DepthLimit      LimitKind = "nesting depth"
+	// ClausesLimit is exceeded by ToCNF and ToDNF, rather than by searches
+	ClausesLimit LimitKind = "number of clauses"
+	// PermutationsLimit is exceeded by ToRegexp, rather than by searches
+	PermutationsLimit LimitKind = "number of permutations"
+)
+
+// LimitError is returned when a condition or target exceeds Limits
+type LimitError struct {
+	// Kind is the limit that was exceeded
+	Kind LimitKind
+	// Max is the value of the limit
+	Max int
+	// Actual is the value that exceeded the limit
+	Actual int
+}
+
+func (err *LimitError) Error() string {
+	return "limit exceeded, " + string(err.Kind) + " of " + strconv.Itoa(err.Actual) + " is over the maximum of " + strconv.Itoa(err.Max)
+}
+
+// contextCheckInterval is the number of bytes of the target searched between checks for cancellation
+const contextCheckInterval = 1 << 20
+
+// SearchStringContext is SearchString with cancellation and limits.
+//
+// The error is a pos_error.PosError if the condition is invalid, a *LimitError if a limit is exceeded,
+// or the error of ctx if ctx is done before the search completes.
+func SearchStringContext(ctx context.Context, command string, target string, limits Limits) (bool, error) {
+	return SearchStringCustomContext(ctx, types.DefaultTokensDefinition, command, target, limits)
+}
+
+// SearchStringCustomContext is SearchStringCustom with cancellation and limits. See SearchStringContext for the errors.
+func SearchStringCustomContext(ctx context.Context, defs types.TokensDefinition, command string, target string, limits Limits) (bool, error) {
+	preparedTokens, err := LexIntoTokens(defs, command)
+	if err != nil {
+		return false, err
+	}
+
+	return SearchTokensContext(ctx, preparedTokens, target, limits)
+}
+
+// SearchTokensContext is SearchTokens with cancellation and limits. See SearchStringContext for the errors.
+//
+// ctx is checked before the search starts, and then periodically while the target is searched,
+// so that a search of a huge target returns soon after ctx is cancelled.
+func SearchTokensContext(ctx context.Context, preparation PreparedTokens, target string, limits Limits) (bool, error) {
+	if limits.MaxTargetSize > 0 && len(target) > limits.MaxTargetSize {
+		return false, &LimitError{Kind: TargetSizeLimit, Max: limits.MaxTargetSize, Actual: len(target)}
+	}
+
+	// the limits are checked in a single pass over the tokens before the condition is built, so that the recursive
+	// conversion of a condition over the limits is never started
+	if terms, depth, ok := measure(preparation); ok {
+		if limits.MaxTerms > 0 && terms > limits.MaxTerms {
+			return false, &LimitError{Kind: TermsLimit, Max: limits.MaxTerms, Actual: terms}
+		}
+		if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+			return false, &LimitError{Kind: DepthLimit, Max: limits.MaxDepth, Actual: depth}
+		}
+	}
+
+	tree, posErr := buildTree(preparation)
+	if posErr != nil {
+		return false, posErr
+	}
+	condition := toExpr(tree)
+
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	var ctxErr error
+	result := condition.eval(func(term string) bool {
+		if ctxErr != nil {
+			return false
+		}
+		var found bool
+		found, ctxErr = containsContext(ctx, target, term)
+		return found
+	})
+
+	if ctxErr != nil {
+		return false, ctxErr
+	}
+
+	return result, nil
+}
+
+// containsContext is strings.Contains, checking whether ctx is done after every contextCheckInterval bytes of target
+func containsContext(ctx context.Context, target string, term string) (bool, error) {
+	for start := 0; ; start += contextCheckInterval {
+		// windows overlap by the length of term, less one, so matches spanning two windows are found
+		end := start + contextCheckInterval + len(term) - 1
+		if end >= len(target) {
+			return strings.Contains(target[start:], term), nil
+		}
+
+		if strings.Contains(target[start:end], term) {
+			return true, nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+	}
+}
+
+// measured is what measure knows of an expr that toExpr would build, without building it
+type measured struct {
+	kind exprKind
+	// depth is the number of nested operators above the deepest expression, as MaxDepth counts it
+	depth int
+	// inner is the kind of the operand of a notExpr, which notOf unwraps
+	inner exprKind
+}
+
+// measure counts the expressions in postfix, and gets the nesting depth of the expr toExpr would build from it,
+// without recursion. It is not ok if postfix is not a single condition, which buildTree reports.
+func measure(postfix []types.Token) (terms int, depth int, ok bool) {
+	var stack []measured
+	for _, tok := range postfix {
+		switch {
+		case types.IsOp(tok.Typ):
+			if len(stack) < 2 {
+				return 0, 0, false
+			}
+			left, right := stack[len(stack)-2], stack[len(stack)-1]
+			var joined measured
+			switch tok.Typ {
+			case types.AND:
+				joined = measureJoin(andExpr, left, right)
+			case types.OR:
+				joined = measureJoin(orExpr, left, right)
+			case types.ANDNOT:
+				joined = measureJoin(andExpr, left, measureNot(right))
+			default: // types.ORNOT
+				joined = measureJoin(orExpr, left, measureNot(right))
+			}
+			stack = append(stack[:len(stack)-2], joined)
+		case tok.Typ == types.EXP:
+			terms++
+			stack = append(stack, measured{kind: termExpr, depth: 1})
+		case tok.Typ == types.TRUE:
+			stack = append(stack, measured{kind: trueExpr, depth: 1})
+		default:
+			return 0, 0, false
+		}
+	}
+
+	if len(stack) != 1 {
+		return 0, 0, false
+	}
+	return terms, stack[0].depth, true
+}
+
+// measureJoin is joinExpr for measured operands
+func measureJoin(kind exprKind, operands ...measured) measured {
+	identity, absorbing := trueExpr, falseExpr
+	if kind == orExpr {
+		identity, absorbing = falseExpr, trueExpr
+	}
+
+	args, deepest := 0, 0
+	var single measured
+	for _, operand := range operands {
+		switch operand.kind {
+		case identity:
+		case absorbing:
+			return operand
+		case kind:
+			// a joined operand has at least two operands of its own, each less deep than it
+			args += 2
+			if operand.depth-1 > deepest {
+				deepest = operand.depth - 1
+			}
+		default:
+			args++
+			single = operand
+			if operand.depth > deepest {
+				deepest = operand.depth
+			}
+		}
+	}
+
+	switch args {
+	case 0:
+		return measured{kind: identity, depth: 1}
+	case 1:
+		return single
+	}
+	return measured{kind: kind, depth: deepest + 1}
+}
+
+// measureNot is notOf for a measured operand
+func measureNot(m measured) measured {
+	switch m.kind {
+	case notExpr:
+		return measured{kind: m.inner, depth: m.depth}
+	case trueExpr:
+		return measured{kind: falseExpr, depth: 1}
+	case falseExpr:
+		return measured{kind: trueExpr, depth: 1}
+	}
+	return measured{kind: notExpr, depth: m.depth, inner: m.kind}
+}
@@ -0,0 +1,119 @@
+package stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+)
+
+// exprKind is the kind of logical expression an expr represents
+type exprKind int
+
+const (
+	termExpr exprKind = iota
+	trueExpr
+	falseExpr
+	notExpr
+	andExpr
+	orExpr
+)
+
+// expr is a condition as a logical formula over its terms.
+//
+// Unlike node, which mirrors the tokens, the complex operators are split into a conjunction or disjunction with an
+// inversion, and chains of the same operator are flattened into one expr with many args.
+type expr struct {
+	kind exprKind
+	// term is the expression text of a termExpr
+	term string
+	// args are the operands of a notExpr (exactly one), andExpr or orExpr
+	args []*expr
+}
+
+// toExpr converts the tree n into a logical formula
+func toExpr(n *node) *expr {
+	switch n.tok.Typ {
+	case types.TRUE:
+		return &expr{kind: trueExpr}
+	case types.EXP:
+		return &expr{kind: termExpr, term: n.tok.Exp}
+	case types.AND:
+		return joinExpr(andExpr, toExpr(n.left), toExpr(n.right))
+	case types.OR:
+		return joinExpr(orExpr, toExpr(n.left), toExpr(n.right))
+	case types.ANDNOT:
+		return joinExpr(andExpr, toExpr(n.left), notOf(toExpr(n.right)))
+	default: // types.ORNOT
+		return joinExpr(orExpr, toExpr(n.left), notOf(toExpr(n.right)))
+	}
+}
+
+// joinExpr joins operands with a conjunction or disjunction, flattening operands of the same kind and dropping
+// constants that have no effect
+func joinExpr(kind exprKind, operands ...*expr) *expr {
+	identity, absorbing := trueExpr, falseExpr
+	if kind == orExpr {
+		identity, absorbing = falseExpr, trueExpr
+	}
+
+	joined := &expr{kind: kind}
+	for _, operand := range operands {
+		switch operand.kind {
+		case identity:
+		case absorbing:
+			return operand
+		case kind:
+			joined.args = append(joined.args, operand.args...)
+		default:
+			joined.args = append(joined.args, operand)
+		}
+	}
+
+	switch len(joined.args) {
+	case 0:
+		return &expr{kind: identity}
+	case 1:
+		return joined.args[0]
+	}
+	return joined
+}
+
+// notOf inverts e, removing double inversions and inverting constants
+func notOf(e *expr) *expr {
+	switch e.kind {
+	case notExpr:
+		return e.args[0]
+	case trueExpr:
+		return &expr{kind: falseExpr}
+	case falseExpr:
+		return &expr{kind: trueExpr}
+	}
+	return &expr{kind: notExpr, args: []*expr{e}}
+}
+
+// eval evaluates e, using matches to determine if each term is found.
+// Operands are evaluated in order and evaluation stops as soon as the result is known.
+func (e *expr) eval(matches func(term string) bool) bool {
+	switch e.kind {
+	case termExpr:
+		return matches(e.term)
+	case trueExpr:
+		return true
+	case falseExpr:
+		return false
+	case notExpr:
+		return !e.args[0].eval(matches)
+	case andExpr:
+		for _, arg := range e.args {
+			if !arg.eval(matches) {
+				return false
+			}
+		}
+		return true
+	default: // orExpr
+		for _, arg := range e.args {
+			if arg.eval(matches) {
+				return true
+			}
+		}
+		return false
+	}
+}
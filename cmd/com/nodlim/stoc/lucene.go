@@ -0,0 +1,251 @@
+package stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/pos_error"
+	"strings"
+	"unicode"
+)
+
+// LexLuceneIntoTokens produces postfix tokens from a query string in the style of Lucene and Gmail, such as
+// lazy -fox "big dog" OR cat. The tokens are the same as LexIntoTokens produces for the same condition, so they can be
+// searched, encoded or formatted like any other PreparedTokens.
+//
+// The syntax is:
+//   - terms separated by whitespace must all be found, as if joined by AND
+//   - AND or &&, OR or ||, and NOT, in capitals; AND binds tighter than OR, as a AND b OR c is (a AND b) OR c
+//   - -term, -"phrase" or -(group) inverts, as NOT does, and +term is the same as term
+//   - ! inverts as - does, as in !term, !"phrase" or !(group)
+//   - "exact phrase" for expressions containing whitespace, brackets or keywords
+//   - ( and ) for groups
+//   - \ escapes the next character, in terms and phrases
+//
+// A term such as field:"some value" is kept whole, so it can be used with SearchRecord.
+// Errors are positioned like those of LexIntoTokens, counted in runes.
+func LexLuceneIntoTokens(query string) (PreparedTokens, pos_error.PosError) {
+	tokens, err := lexLucene([]rune(query))
+	if err != nil {
+		return nil, err
+	}
+
+	p := luceneParser{tokens: tokens}
+	q, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != luceneEnd {
+		return nil, pos_error.New("lucene error, unexpected "+next.text, next.pos)
+	}
+	return q.Tokens(), nil
+}
+
+// SearchLuceneString searches target with a query string in the style of Lucene and Gmail.
+// See LexLuceneIntoTokens for the syntax.
+func SearchLuceneString(query string, target string) (bool, pos_error.PosError) {
+	preparedTokens, err := LexLuceneIntoTokens(query)
+	if err != nil {
+		return false, err
+	}
+	return SearchTokens(preparedTokens, target), nil
+}
+
+// luceneKind is the kind of a token of a Lucene query
+type luceneKind int
+
+const (
+	luceneTerm luceneKind = iota
+	luceneAnd
+	luceneOr
+	luceneNot
+	luceneOpen
+	luceneClose
+	luceneEnd
+)
+
+// luceneToken is a token of a Lucene query, with its text and position
+type luceneToken struct {
+	kind luceneKind
+	text string
+	pos  int
+}
+
+// lexLucene splits a Lucene query into tokens
+func lexLucene(query []rune) ([]luceneToken, pos_error.PosError) {
+	var tokens []luceneToken
+	i := 0
+	for i < len(query) {
+		c := query[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, luceneToken{kind: luceneOpen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, luceneToken{kind: luceneClose, text: ")", pos: i})
+			i++
+		case c == '-' || c == '+' || c == '!':
+			if i+1 == len(query) || unicode.IsSpace(query[i+1]) || query[i+1] == ')' {
+				return nil, pos_error.New("lucene error, "+string(c)+" must be followed by a term", i)
+			}
+			if c != '+' {
+				tokens = append(tokens, luceneToken{kind: luceneNot, text: string(c), pos: i})
+			}
+			i++
+		case c == '"':
+			text, end, err := lexLucenePhrase(query, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, luceneToken{kind: luceneTerm, text: text, pos: i})
+			i = end
+		default:
+			text, raw, end, err := lexLuceneWord(query, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, luceneToken{kind: lucenePlainKind(raw), text: text, pos: i})
+			i = end
+		}
+	}
+	return append(tokens, luceneToken{kind: luceneEnd, text: "end of query", pos: len(query)}), nil
+}
+
+// lexLucenePhrase reads the quoted phrase starting at start, returning its text without quotes and the index after it
+func lexLucenePhrase(query []rune, start int) (string, int, pos_error.PosError) {
+	var sb strings.Builder
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if i+1 == len(query) {
+				return "", 0, pos_error.New("lucene error, \\ must be followed by a character", i)
+			}
+			i++
+			sb.WriteRune(query[i])
+		case '"':
+			return sb.String(), i + 1, nil
+		default:
+			sb.WriteRune(query[i])
+		}
+	}
+	return "", 0, pos_error.New("lucene error, missing closing quote", start)
+}
+
+// lexLuceneWord reads the word starting at start, returning its text with escapes applied, the text as written and
+// the index after it. Quotes inside a word, as in field:"some value", are kept along with the text between them.
+func lexLuceneWord(query []rune, start int) (string, string, int, pos_error.PosError) {
+	var sb strings.Builder
+	i := start
+	for i < len(query) && !unicode.IsSpace(query[i]) && query[i] != '(' && query[i] != ')' {
+		switch query[i] {
+		case '\\':
+			if i+1 == len(query) {
+				return "", "", 0, pos_error.New("lucene error, \\ must be followed by a character", i)
+			}
+			sb.WriteRune(query[i+1])
+			i += 2
+		case '"':
+			end := i + 1
+			for end < len(query) && query[end] != '"' {
+				end++
+			}
+			if end == len(query) {
+				return "", "", 0, pos_error.New("lucene error, missing closing quote", i)
+			}
+			sb.WriteString(string(query[i : end+1]))
+			i = end + 1
+		default:
+			sb.WriteRune(query[i])
+			i++
+		}
+	}
+	return sb.String(), string(query[start:i]), i, nil
+}
+
+// lucenePlainKind is the kind of a word as it was written, so that escaped keywords such as \AND are terms
+func lucenePlainKind(raw string) luceneKind {
+	switch raw {
+	case "AND", "&&":
+		return luceneAnd
+	case "OR", "||":
+		return luceneOr
+	case "NOT":
+		return luceneNot
+	}
+	return luceneTerm
+}
+
+// luceneParser builds a Query from the tokens of a Lucene query, by recursive descent
+type luceneParser struct {
+	tokens []luceneToken
+	next   int
+}
+
+func (p *luceneParser) peek() luceneToken {
+	return p.tokens[p.next]
+}
+
+// or parses terms joined by OR
+func (p *luceneParser) or() (Query, pos_error.PosError) {
+	q, err := p.and()
+	if err != nil {
+		return Query{}, err
+	}
+	for p.peek().kind == luceneOr {
+		p.next++
+		other, err := p.and()
+		if err != nil {
+			return Query{}, err
+		}
+		q = q.Or(other)
+	}
+	return q, nil
+}
+
+// and parses terms joined by AND, or by nothing but whitespace
+func (p *luceneParser) and() (Query, pos_error.PosError) {
+	q, err := p.unary()
+	if err != nil {
+		return Query{}, err
+	}
+	for {
+		switch p.peek().kind {
+		case luceneAnd:
+			p.next++
+		case luceneTerm, luceneNot, luceneOpen:
+		default:
+			return q, nil
+		}
+		other, err := p.unary()
+		if err != nil {
+			return Query{}, err
+		}
+		q = q.And(other)
+	}
+}
+
+// unary parses a term, phrase or group, with any number of inversions
+func (p *luceneParser) unary() (Query, pos_error.PosError) {
+	tok := p.peek()
+	p.next++
+	switch tok.kind {
+	case luceneNot:
+		q, err := p.unary()
+		if err != nil {
+			return Query{}, err
+		}
+		return Not(q), nil
+	case luceneTerm:
+		return Term(tok.text), nil
+	case luceneOpen:
+		q, err := p.or()
+		if err != nil {
+			return Query{}, err
+		}
+		if closing := p.peek(); closing.kind != luceneClose {
+			return Query{}, pos_error.New("lucene error, missing closing bracket", tok.pos)
+		}
+		p.next++
+		return q, nil
+	}
+	return Query{}, pos_error.New("lucene error, expected a term but found "+tok.text, tok.pos)
+}
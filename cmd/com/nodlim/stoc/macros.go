@@ -0,0 +1,156 @@
+package stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/lexer"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/pos_error"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"strings"
+	"unicode"
+)
+
+// Macros are named conditions, such as a list of noisy log signatures shared by many filters.
+// A condition refers to a macro by its name after @, as in error &! @noise. See LexIntoTokensWithMacros.
+type Macros map[string]string
+
+// macroSigil starts a reference to a macro
+const macroSigil = '@'
+
+// MacroError is an error in the condition of a macro
+type MacroError struct {
+	// Name is the macro with the error
+	Name string
+	// Err is the error, positioned in the condition of the macro
+	Err pos_error.PosError
+}
+
+func (err *MacroError) Error() string {
+	return "in macro " + string(macroSigil) + err.Name + ", " + err.Err.Error()
+}
+
+// GetPos is the position of the error in the condition of the macro
+func (err *MacroError) GetPos() int {
+	return err.Err.GetPos()
+}
+
+func (err *MacroError) Unwrap() error {
+	return err.Err
+}
+
+// LexIntoTokensWithMacros produces postfix tokens from TokensDefinition and raw tokens-to-be command, as LexIntoTokens
+// does, where command and the macros can refer to macros as @name. Each reference is replaced by the condition of the
+// macro, in brackets, so lazy & @noise with the macro noise = dog | cat is lazy & (dog | cat).
+//
+// A reference is an expression that is not quoted, made of @ and a name of letters, digits and underscores, so
+// '@noise' and user@example.com are plain expressions. Macros are written in the syntax of defs, and each is lexed
+// once however often it is referred to.
+//
+// An error in command is positioned in command, as for LexIntoTokens, including references to macros that are not
+// defined and references that make a cycle, such as a macro referring to itself. An error in the condition of a macro
+// is a *MacroError naming the macro, positioned in its condition.
+func LexIntoTokensWithMacros(defs types.TokensDefinition, command string, macros Macros) (PreparedTokens, pos_error.PosError) {
+	e := &macroExpander{defs: defs, lex: definitions(defs), macros: macros, expanded: map[string][]types.Token{}}
+	tokens, err := e.expand([]rune(command))
+	if err != nil {
+		return nil, err
+	}
+
+	result, errShunt := lexer.TokenShuntingAlgorithm(tokens)
+	if errShunt != nil {
+		return nil, errShunt
+	}
+	return result, nil
+}
+
+// macroExpander replaces references to macros with their tokens
+type macroExpander struct {
+	defs   types.TokensDefinition
+	lex    lexer.Definitions
+	macros Macros
+	// expanded are the infix tokens of each macro lexed so far, with their references replaced
+	expanded map[string][]types.Token
+	// expanding are the macros being lexed, outermost first, which a reference to would make a cycle
+	expanding []string
+}
+
+// expand lexes condition into infix tokens, replacing each reference to a macro with its tokens in brackets
+func (e *macroExpander) expand(condition []rune) ([]types.Token, pos_error.PosError) {
+	lexed, err := lexCondition(e.defs, e.lex, condition)
+	if err != nil {
+		return nil, err
+	}
+
+	// the shunting algorithm validates the brackets, so that macros are only expanded in valid conditions
+	if _, err = lexer.TokenShuntingAlgorithm(lexed.tokens); err != nil {
+		return nil, err
+	}
+
+	spans, quoted := locateTokens(e.defs, e.lex, lexed.raw, lexed.written)
+	tokens := make([]types.Token, 0, len(lexed.tokens))
+	for i, tok := range lexed.tokens {
+		name, ok := macroReference(lexed.written[i])
+		if !ok || quoted[i] {
+			tokens = append(tokens, tok)
+			continue
+		}
+
+		body, err := e.macro(name, lexed.origin(spans[i].start))
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, types.Token{Typ: types.LBR, Exp: keyOf(e.defs, types.LBR)})
+		tokens = append(tokens, body...)
+		tokens = append(tokens, types.Token{Typ: types.RBR, Exp: keyOf(e.defs, types.RBR)})
+	}
+	return tokens, nil
+}
+
+// macro returns the infix tokens of the macro name, referred to at pos
+func (e *macroExpander) macro(name string, pos int) ([]types.Token, pos_error.PosError) {
+	if tokens, ok := e.expanded[name]; ok {
+		return tokens, nil
+	}
+
+	for i, expanding := range e.expanding {
+		if expanding == name {
+			cycle := append(append([]string{}, e.expanding[i:]...), name)
+			return nil, pos_error.New("macro cycle, "+string(macroSigil)+strings.Join(cycle, " refers to "+string(macroSigil)), pos)
+		}
+	}
+
+	body, ok := e.macros[name]
+	if !ok {
+		return nil, pos_error.New("undefined macro "+string(macroSigil)+name+", quote it to search for it", pos)
+	}
+
+	e.expanding = append(e.expanding, name)
+	tokens, err := e.expand([]rune(body))
+	e.expanding = e.expanding[:len(e.expanding)-1]
+	if err != nil {
+		// errors in macros referred to by this one are already attributed to them
+		if _, nested := err.(*MacroError); nested {
+			return nil, err
+		}
+		return nil, &MacroError{Name: name, Err: err}
+	}
+
+	e.expanded[name] = tokens
+	return tokens, nil
+}
+
+// macroReference returns the name of the macro tok refers to, as it was written, and whether it is a reference
+func macroReference(tok types.Token) (string, bool) {
+	if tok.Typ != types.EXP {
+		return "", false
+	}
+
+	runes := []rune(tok.Exp)
+	if len(runes) < 2 || runes[0] != macroSigil {
+		return "", false
+	}
+	for _, r := range runes[1:] {
+		if !(r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return "", false
+		}
+	}
+	return string(runes[1:]), true
+}
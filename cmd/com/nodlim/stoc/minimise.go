@@ -0,0 +1,238 @@
+package stoc
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// maxMinimiseTerms is the largest number of distinct expressions Minimise will minimise.
+// Minimisation enumerates every combination of the expressions being found, so the cost doubles with each one.
+const maxMinimiseTerms = 8
+
+// Minimise rewrites pre-prepared tokens into an equivalent condition that is as small as it can find.
+//
+// The condition is first simplified with Simplify. If it has no more than 8 distinct expressions,
+// it is then minimised with the Quine–McCluskey algorithm, in both disjunctive form (a | b & !c) and conjunctive form
+// (a & (b | !c)), and the smallest of the three is returned. Conditions with more expressions are only simplified.
+//
+// Expressions are treated as independent of each other, so a condition that is only equivalent because one
+// expression contains another, such as fox & !fo, is not reduced.
+//
+// An error is returned if the tokens are not a valid postfix condition.
+func Minimise(preparation PreparedTokens) (PreparedTokens, error) {
+	tree, err := buildTree(preparation)
+	if err != nil {
+		return nil, err
+	}
+
+	best := fromExpr(simplifyExpr(toExpr(tree))).Tokens()
+	terms := toExpr(tree).terms()
+	if len(terms) > maxMinimiseTerms {
+		return best, nil
+	}
+
+	table := truthTable(toExpr(tree), terms)
+	candidates := []*expr{
+		minimalSumOfProducts(terms, table, true),
+		notOf(minimalSumOfProducts(terms, table, false)),
+	}
+
+	for _, candidate := range candidates {
+		if tokens := fromExpr(simplifyExpr(pushNegations(candidate))).Tokens(); len(tokens) < len(best) {
+			best = tokens
+		}
+	}
+	return best, nil
+}
+
+// terms returns the distinct expressions of e in the order they first appear
+func (e *expr) terms() []string {
+	var terms []string
+	seen := map[string]bool{}
+	var walk func(*expr)
+	walk = func(cur *expr) {
+		if cur.kind == termExpr && !seen[cur.term] {
+			seen[cur.term] = true
+			terms = append(terms, cur.term)
+		}
+		for _, arg := range cur.args {
+			walk(arg)
+		}
+	}
+	walk(e)
+	return terms
+}
+
+// truthTable evaluates e for every combination of terms being found.
+// Bit i of the index into the result is whether terms[i] is found.
+func truthTable(e *expr, terms []string) []bool {
+	index := make(map[string]int, len(terms))
+	for i, term := range terms {
+		index[term] = i
+	}
+
+	table := make([]bool, 1<<len(terms))
+	for assignment := range table {
+		table[assignment] = e.eval(func(term string) bool {
+			return assignment&(1<<index[term]) != 0
+		})
+	}
+	return table
+}
+
+// implicant is a product of terms, covering every assignment that matches value in the bits not set in mask
+type implicant struct {
+	value uint32
+	mask  uint32
+}
+
+// covers returns true if the implicant covers assignment
+func (imp implicant) covers(assignment int) bool {
+	return uint32(assignment)&^imp.mask == imp.value
+}
+
+// minimalSumOfProducts finds a small disjunction of conjunctions covering every assignment where table is equal to
+// want, using the Quine–McCluskey algorithm to find prime implicants and then choosing essential and covering primes
+func minimalSumOfProducts(terms []string, table []bool, want bool) *expr {
+	var minterms []int
+	for assignment, result := range table {
+		if result == want {
+			minterms = append(minterms, assignment)
+		}
+	}
+
+	cover := coverMinterms(primeImplicants(minterms), minterms)
+
+	products := make([]*expr, len(cover))
+	for i, imp := range cover {
+		var positives, negatives []*expr
+		for t, term := range terms {
+			bit := uint32(1) << t
+			if imp.mask&bit != 0 {
+				continue
+			} else if imp.value&bit != 0 {
+				positives = append(positives, &expr{kind: termExpr, term: term})
+			} else {
+				negatives = append(negatives, notOf(&expr{kind: termExpr, term: term}))
+			}
+		}
+		products[i] = joinExpr(andExpr, append(positives, negatives...)...)
+	}
+	return joinExpr(orExpr, products...)
+}
+
+// primeImplicants combines minterms differing in one term, repeatedly, until no more can be combined
+func primeImplicants(minterms []int) []implicant {
+	current := make([]implicant, len(minterms))
+	for i, minterm := range minterms {
+		current[i] = implicant{value: uint32(minterm)}
+	}
+
+	var primes []implicant
+	for len(current) > 0 {
+		combined := map[implicant]bool{}
+		used := make([]bool, len(current))
+		for i := range current {
+			for j := i + 1; j < len(current); j++ {
+				a, b := current[i], current[j]
+				diff := a.value ^ b.value
+				if a.mask == b.mask && bits.OnesCount32(diff) == 1 {
+					combined[implicant{value: a.value &^ diff, mask: a.mask | diff}] = true
+					used[i], used[j] = true, true
+				}
+			}
+		}
+
+		for i, imp := range current {
+			if !used[i] {
+				primes = append(primes, imp)
+			}
+		}
+
+		current = current[:0]
+		for imp := range combined {
+			current = append(current, imp)
+		}
+		sort.Slice(current, func(i, j int) bool {
+			return current[i].mask < current[j].mask || (current[i].mask == current[j].mask && current[i].value < current[j].value)
+		})
+	}
+	return primes
+}
+
+// coverMinterms chooses primes covering every minterm: first the essential primes, which are the only prime covering
+// some minterm, then repeatedly the prime covering the most remaining minterms
+func coverMinterms(primes []implicant, minterms []int) []implicant {
+	var cover []implicant
+	remaining := map[int]bool{}
+	for _, minterm := range minterms {
+		remaining[minterm] = true
+	}
+
+	choose := func(imp implicant) {
+		cover = append(cover, imp)
+		for minterm := range remaining {
+			if imp.covers(minterm) {
+				delete(remaining, minterm)
+			}
+		}
+	}
+
+	for _, minterm := range minterms {
+		var only []implicant
+		for _, imp := range primes {
+			if imp.covers(minterm) {
+				only = append(only, imp)
+			}
+		}
+		if len(only) == 1 && remaining[minterm] {
+			choose(only[0])
+		}
+	}
+
+	for len(remaining) > 0 {
+		best, bestCount := primes[0], -1
+		for _, imp := range primes {
+			count := 0
+			for minterm := range remaining {
+				if imp.covers(minterm) {
+					count++
+				}
+			}
+			// prefer implicants with fewer terms, which have more bits in the mask, when they cover as many
+			if count > bestCount || (count == bestCount && bits.OnesCount32(imp.mask) > bits.OnesCount32(best.mask)) {
+				best, bestCount = imp, count
+			}
+		}
+		choose(best)
+	}
+
+	return cover
+}
+
+// pushNegations moves inversions of conjunctions and disjunctions onto their operands, using De Morgan's laws
+func pushNegations(e *expr) *expr {
+	switch e.kind {
+	case notExpr:
+		inner := e.args[0]
+		if inner.kind != andExpr && inner.kind != orExpr {
+			return e
+		}
+		kind := orExpr
+		if inner.kind == orExpr {
+			kind = andExpr
+		}
+		operands := make([]*expr, len(inner.args))
+		for i, arg := range inner.args {
+			operands[i] = pushNegations(notOf(arg))
+		}
+		return joinExpr(kind, operands...)
+	case andExpr, orExpr:
+		operands := make([]*expr, len(e.args))
+		for i, arg := range e.args {
+			operands[i] = pushNegations(arg)
+		}
+		return joinExpr(e.kind, operands...)
+	}
+	return e
+}
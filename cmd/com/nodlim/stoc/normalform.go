@@ -0,0 +1,223 @@
+package stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+)
+
+// DefaultMaxClauses is the number of clauses ToCNF and ToDNF allow when they are given no maximum
+const DefaultMaxClauses = 1024
+
+// NormalFormKind is the kind of normal form a NormalForm is in
+type NormalFormKind string
+
+const (
+	// CNF is conjunctive normal form, where the condition is met if every clause has a literal that is met,
+	// such as (a | b) & (!c | d)
+	CNF NormalFormKind = "CNF"
+	// DNF is disjunctive normal form, where the condition is met if any clause has every literal met,
+	// such as a & b | !c & d
+	DNF NormalFormKind = "DNF"
+)
+
+// Literal is an expression of a normal form, which is met if the target contains Exp, or if Negated is true,
+// if the target does not contain Exp
+type Literal struct {
+	Exp     string
+	Negated bool
+}
+
+// Clause is the literals of one clause of a normal form. In CNF they are joined with or, in DNF with and.
+type Clause []Literal
+
+// NormalForm is a condition in conjunctive or disjunctive normal form.
+//
+// A CNF with no clauses is met by every target, and a CNF clause with no literals is never met.
+// Likewise a DNF with no clauses is never met, and a DNF clause with no literals is met by every target.
+type NormalForm struct {
+	Kind    NormalFormKind
+	Clauses []Clause
+}
+
+// ToCNF converts pre-prepared tokens into an equivalent condition in conjunctive normal form, such as
+// (a | b) & (!c | d).
+//
+// Converting can multiply the number of clauses, so conversion stops with a *LimitError of kind ClausesLimit
+// once more than maxClauses are needed, even if later steps would have removed some of them.
+// A zero or negative maxClauses means DefaultMaxClauses.
+// Clauses that are always met, such as a | !a, and clauses that contain another clause are removed.
+//
+// An error is returned if the tokens are not a valid postfix condition.
+func ToCNF(preparation PreparedTokens, maxClauses int) (*NormalForm, error) {
+	return toNormalForm(preparation, CNF, maxClauses)
+}
+
+// ToDNF converts pre-prepared tokens into an equivalent condition in disjunctive normal form, such as
+// a & b | !c & d. It is the dual of ToCNF, see it for the limit on the number of clauses.
+func ToDNF(preparation PreparedTokens, maxClauses int) (*NormalForm, error) {
+	return toNormalForm(preparation, DNF, maxClauses)
+}
+
+// Query converts the normal form into a Query, so it can be searched for, formatted, or built on
+func (nf *NormalForm) Query() Query {
+	outer, inner := AllOf, AnyOf
+	if nf.Kind == DNF {
+		outer, inner = AnyOf, AllOf
+	}
+
+	clauses := make([]Query, len(nf.Clauses))
+	for i, clause := range nf.Clauses {
+		literals := make([]Query, len(clause))
+		for j, literal := range clause {
+			literals[j] = Term(literal.Exp)
+			if literal.Negated {
+				literals[j] = Not(literals[j])
+			}
+		}
+		clauses[i] = inner(literals...)
+	}
+	return outer(clauses...)
+}
+
+// Tokens compiles the normal form into postfix tokens
+func (nf *NormalForm) Tokens() PreparedTokens {
+	return nf.Query().Tokens()
+}
+
+// Format renders the normal form as a condition in the syntax defined by defs. See the Format function.
+func (nf *NormalForm) Format(defs types.TokensDefinition) (string, error) {
+	return nf.Query().Format(defs)
+}
+
+// toNormalForm converts pre-prepared tokens into the normal form kind
+func toNormalForm(preparation PreparedTokens, kind NormalFormKind, maxClauses int) (*NormalForm, error) {
+	tree, err := buildTree(preparation)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxClauses <= 0 {
+		maxClauses = DefaultMaxClauses
+	}
+
+	// the clauses of a CNF are joined by and, so an and of two CNFs is their clauses together,
+	// while an or of two CNFs needs every pairing of their clauses. DNF is the opposite.
+	outer := andExpr
+	if kind == DNF {
+		outer = orExpr
+	}
+
+	n := normaliser{outer: outer, max: maxClauses}
+	clauses, limitErr := n.clauses(pushNegations(simplifyExpr(toExpr(tree))))
+	if limitErr != nil {
+		return nil, limitErr
+	}
+	return &NormalForm{Kind: kind, Clauses: clauses}, nil
+}
+
+// normaliser converts expressions into the clauses of a normal form
+type normaliser struct {
+	// outer is the kind of expression that joins the clauses
+	outer exprKind
+	// max is the maximum number of clauses
+	max int
+}
+
+// clauses converts e, which must have inversions only on terms, into clauses
+func (n normaliser) clauses(e *expr) ([]Clause, error) {
+	switch e.kind {
+	case termExpr:
+		return []Clause{{{Exp: e.term}}}, nil
+	case notExpr:
+		return []Clause{{{Exp: e.args[0].term, Negated: true}}}, nil
+	case trueExpr, falseExpr:
+		// the identity of the outer operator is no clauses, its absorbing value is one empty clause
+		if (e.kind == trueExpr) == (n.outer == andExpr) {
+			return nil, nil
+		}
+		return []Clause{{}}, nil
+	}
+
+	var result []Clause
+	for i, arg := range e.args {
+		clauses, err := n.clauses(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			result = clauses
+		} else if e.kind == n.outer {
+			result = reduceClauses(append(result, clauses...))
+		} else {
+			result = reduceClauses(distribute(result, clauses))
+		}
+
+		if len(result) > n.max {
+			return nil, &LimitError{Kind: ClausesLimit, Max: n.max, Actual: len(result)}
+		}
+	}
+	return result, nil
+}
+
+// distribute pairs every clause of a with every clause of b, joining the literals of each pair
+func distribute(a []Clause, b []Clause) []Clause {
+	result := make([]Clause, 0, len(a)*len(b))
+	for _, x := range a {
+		for _, y := range b {
+			joined := make(Clause, 0, len(x)+len(y))
+			joined = append(joined, x...)
+			result = append(result, append(joined, y...))
+		}
+	}
+	return result
+}
+
+// reduceClauses removes repeated literals from each clause, then removes clauses that contain a literal and its
+// inversion, and clauses that contain every literal of another clause
+func reduceClauses(clauses []Clause) []Clause {
+	sets := make([]map[Literal]bool, 0, len(clauses))
+	kept := make([]Clause, 0, len(clauses))
+	for _, clause := range clauses {
+		set := make(map[Literal]bool, len(clause))
+		var literals Clause
+		complemented := false
+		for _, literal := range clause {
+			if set[literal] {
+				continue
+			}
+			set[literal] = true
+			literals = append(literals, literal)
+			complemented = complemented || set[Literal{Exp: literal.Exp, Negated: !literal.Negated}]
+		}
+		if !complemented {
+			sets = append(sets, set)
+			kept = append(kept, literals)
+		}
+	}
+
+	var result []Clause
+	for i, clause := range kept {
+		absorbed := false
+		for j, other := range kept {
+			// of two identical clauses, only the first is kept
+			if i != j && len(other) <= len(clause) && (len(other) < len(clause) || j < i) && containsAll(sets[i], other) {
+				absorbed = true
+				break
+			}
+		}
+		if !absorbed {
+			result = append(result, clause)
+		}
+	}
+	return result
+}
+
+// containsAll returns true if every literal of clause is in set
+func containsAll(set map[Literal]bool, clause Clause) bool {
+	for _, literal := range clause {
+		if !set[literal] {
+			return false
+		}
+	}
+	return true
+}
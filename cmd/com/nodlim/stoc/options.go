@@ -0,0 +1,507 @@
+package stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/lexer"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/pos_error"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	stoctypes "github.com/kranzuft/stoc/cmd/com/nodlim/stoc/types"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// hasOption returns true if defs enables the option typ, by defining it
+func hasOption(defs types.TokensDefinition, typ types.TokenType) bool {
+	_, ok := defs[typ]
+	return ok
+}
+
+// withOption enables the option typ in defs, returning defs
+func withOption(defs types.TokensDefinition, typ types.TokenType, description string) types.TokensDefinition {
+	defs.DefineTokenInfo(typ, "", description)
+	return defs
+}
+
+// definitions returns how the lexer recognises the keywords of defs, taking the options of defs into account
+func definitions(defs types.TokensDefinition) lexer.Definitions {
+	var lex lexer.Definitions = defs
+	if hasOption(defs, stoctypes.CASE_INSENSITIVE) {
+		lex = caseInsensitiveDefinitions{
+			TokensDefinition: defs,
+			and:              []rune(keyOf(defs, types.AND)),
+			or:               []rune(keyOf(defs, types.OR)),
+			not:              []rune(keyOf(defs, types.NOT)),
+			lbr:              []rune(keyOf(defs, types.LBR)),
+			rbr:              []rune(keyOf(defs, types.RBR)),
+			dquote:           []rune(keyOf(defs, types.DQUOTE)),
+			squote:           []rune(keyOf(defs, types.SQUOTE)),
+		}
+	}
+	if hasOption(defs, stoctypes.ESCAPE) {
+		lex = escapeDefinitions{lex}
+	}
+	return lex
+}
+
+// lexedCondition is a condition lexed into infix tokens, with the options of its syntax applied
+type lexedCondition struct {
+	// raw is the text the lexer read, which has any comments blanked and implicit operators written out
+	raw []rune
+	// origins are the positions in the condition of each rune of raw, and of the end of raw.
+	// If nil, raw is the condition.
+	origins []int
+	// written are the tokens as written in raw, and tokens are the same with any line breaks of expressions folded
+	// and escape sequences applied
+	written []types.Token
+	tokens  []types.Token
+}
+
+// lexCondition lexes condition into infix tokens, with the options of defs applied. Errors are positioned in condition.
+func lexCondition(defs types.TokensDefinition, lex lexer.Definitions, condition []rune) (*lexedCondition, pos_error.PosError) {
+	lc := &lexedCondition{raw: condition}
+	comment := commentKey(defs)
+	if comment != nil {
+		lc.raw = blankComments(lex, comment, lc.raw)
+		// a condition of only comments is as empty as a condition of nothing, rather than an empty expression
+		if isBlank(lc.raw) {
+			return nil, pos_error.New("Empty search text", 0)
+		}
+	}
+	if hasOption(defs, stoctypes.IMPLICIT_AND) {
+		lc.raw, lc.origins = writeImplicitAnd(lex, []rune(keyOf(defs, types.AND)), lc.raw)
+	}
+
+	escapes := hasOption(defs, stoctypes.ESCAPE)
+	if escapes {
+		if err := validateEscapes(lc.raw); err != nil {
+			return nil, pos_error.New(err.Error(), lc.origin(err.GetPos()))
+		}
+	}
+
+	tokens, err := lexer.BooleanAlgebraLexer(lex, lc.raw)
+	if err != nil {
+		return nil, pos_error.New(err.Error(), lc.origin(err.GetPos()))
+	}
+	lc.written, lc.tokens = tokens, tokens
+	if escapes || comment != nil {
+		var quoted []bool
+		if comment != nil {
+			_, quoted = locateTokens(defs, lex, lc.raw, tokens)
+		}
+
+		lc.tokens = make([]types.Token, len(tokens))
+		for i, tok := range tokens {
+			if tok.Typ == types.EXP && comment != nil && !quoted[i] {
+				tok.Exp = foldLines(tok.Exp)
+			}
+			if tok.Typ == types.EXP && escapes {
+				tok.Exp = unescape(tok.Exp)
+			}
+			lc.tokens[i] = tok
+		}
+	}
+	return lc, nil
+}
+
+// isBlank returns true if raw is only whitespace, as the lexer skips between tokens
+func isBlank(raw []rune) bool {
+	for i := range raw {
+		if !types.IsWhitespace(raw, i) {
+			return false
+		}
+	}
+	return true
+}
+
+// origin returns the position in the condition of the position pos in raw
+func (lc *lexedCondition) origin(pos int) int {
+	if lc.origins == nil || pos < 0 {
+		return pos
+	}
+	if last := len(lc.origins) - 1; pos > last {
+		// the lexer can position errors past the end
+		return lc.origins[last] + pos - last
+	}
+	return lc.origins[pos]
+}
+
+// writeImplicitAnd writes out the and keyword between juxtaposed operands of condition, where an expression or
+// right bracket is followed by an expression, left bracket or not. It returns the text with the keywords written out,
+// and the position in condition of each of its runes; written keywords have the position of the operand that follows.
+// Text that cannot be lexed is copied as it is, so the lexer reports the error.
+func writeImplicitAnd(lex lexer.Definitions, and []rune, condition []rune) ([]rune, []int) {
+	raw := make([]rune, 0, len(condition))
+	origins := make([]int, 0, len(condition)+1)
+
+	// afterOperand is true when the last part ends an operand
+	afterOperand := false
+	for _, part := range scanCondition(lex, nil, condition) {
+		if part.startsOperand() && afterOperand {
+			for _, r := range " " + string(and) + " " {
+				raw = append(raw, r)
+				origins = append(origins, part.start)
+			}
+		}
+		if part.kind != scannedSpace {
+			afterOperand = part.endsOperand()
+		}
+
+		raw = append(raw, condition[part.start:part.end]...)
+		for i := part.start; i < part.end; i++ {
+			origins = append(origins, i)
+		}
+	}
+	origins = append(origins, len(condition))
+	return raw, origins
+}
+
+// scannedKind is the kind of a part of a condition
+type scannedKind int
+
+const (
+	scannedSpace scannedKind = iota
+	scannedComment
+	scannedOperator
+	scannedLeftBracket
+	scannedRightBracket
+	scannedNot
+	scannedQuoted
+	scannedBare
+)
+
+// scanned is a part of a condition, from start to end (exclusive)
+type scanned struct {
+	kind  scannedKind
+	start int
+	end   int
+}
+
+// startsOperand returns true if the part is the start of an operand: an expression, left bracket or not
+func (part scanned) startsOperand() bool {
+	return part.kind == scannedLeftBracket || part.kind == scannedNot || part.kind == scannedQuoted || part.kind == scannedBare
+}
+
+// endsOperand returns true if the part is the end of an operand: an expression or right bracket
+func (part scanned) endsOperand() bool {
+	return part.kind == scannedRightBracket || part.kind == scannedQuoted || part.kind == scannedBare
+}
+
+// scanCondition splits condition into the parts the lexer would find, without checking their order, so that options
+// can be applied before lexing. Whitespace is a part of its own, so expressions that are not quoted are split into
+// words. If comment is not nil, a comment starts with it at the start of condition or after whitespace, and runs to
+// the end of the line.
+func scanCondition(lex lexer.Definitions, comment []rune, condition []rune) []scanned {
+	var parts []scanned
+	for i := 0; i < len(condition); {
+		part := scanned{kind: scannedBare, start: i}
+		switch {
+		case types.IsWhitespace(condition, i):
+			part.kind = scannedSpace
+			i++
+		case comment != nil && startsWith(condition, i, comment) && (i == 0 || types.IsWhitespace(condition, i-1)):
+			part.kind = scannedComment
+			for i++; i < len(condition) && condition[i] != '\n'; i++ {
+			}
+		case lex.IsAnd(condition, i):
+			part.kind = scannedOperator
+			i += lex.IsAndI()
+		case lex.IsOr(condition, i):
+			part.kind = scannedOperator
+			i += lex.IsOrI()
+		case lex.IsRightBracket(condition, i):
+			part.kind = scannedRightBracket
+			i += lex.IsRightBracketI()
+		case lex.IsLeftBracket(condition, i):
+			part.kind = scannedLeftBracket
+			i += lex.IsLeftBracketI()
+		case lex.IsNot(condition, i):
+			part.kind = scannedNot
+			i += lex.IsNotI()
+		case lex.IsQuote(condition, i):
+			// as in the lexer, quotes are a single rune, and an expression ends at the same quote it starts with
+			part.kind = scannedQuoted
+			isQuote := lex.IsDoubleInvertedComma
+			if lex.IsSingleInvertedComma(condition, i) {
+				isQuote = lex.IsSingleInvertedComma
+			}
+			for i++; i < len(condition) && !isQuote(condition, i); i++ {
+			}
+			if i < len(condition) {
+				i++
+			}
+		default:
+			for i++; i < len(condition) && lex.IsExpRune(condition, i); i++ {
+			}
+		}
+		part.end = i
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// commentKey returns the key that starts a comment, if defs enables comments, or nil
+func commentKey(defs types.TokensDefinition) []rune {
+	if !hasOption(defs, stoctypes.COMMENT) {
+		return nil
+	}
+	if key := keyOf(defs, stoctypes.COMMENT); key != "" {
+		return []rune(key)
+	}
+	return []rune{'#'}
+}
+
+// blankComments returns condition with its comments, and the carriage returns of line breaks outside of quotes,
+// replaced by spaces, so that the lexer skips them and positions in condition are kept
+func blankComments(lex lexer.Definitions, comment []rune, condition []rune) []rune {
+	raw := append([]rune{}, condition...)
+	for _, part := range scanCondition(lex, comment, condition) {
+		switch part.kind {
+		case scannedComment:
+			for i := part.start; i < part.end; i++ {
+				raw[i] = ' '
+			}
+		case scannedQuoted:
+		default:
+			for i := part.start; i < part.end; i++ {
+				if raw[i] == '\r' && i+1 < len(raw) && raw[i+1] == '\n' {
+					raw[i] = ' '
+				}
+			}
+		}
+	}
+	return raw
+}
+
+// foldLines replaces the whitespace around each line break in exp with a single space
+func foldLines(exp string) string {
+	if !strings.ContainsRune(exp, '\n') {
+		return exp
+	}
+
+	raw := []rune(exp)
+	var sb strings.Builder
+	for i := 0; i < len(raw); {
+		if !types.IsWhitespace(raw, i) {
+			sb.WriteRune(raw[i])
+			i++
+			continue
+		}
+		start := i
+		for i < len(raw) && types.IsWhitespace(raw, i) {
+			i++
+		}
+		if space := string(raw[start:i]); strings.ContainsRune(space, '\n') {
+			sb.WriteRune(' ')
+		} else {
+			sb.WriteString(space)
+		}
+	}
+	return sb.String()
+}
+
+// startsWith returns true if r has key at index
+func startsWith(r []rune, index int, key []rune) bool {
+	if index+len(key) > len(r) {
+		return false
+	}
+	for i, k := range key {
+		if r[index+i] != k {
+			return false
+		}
+	}
+	return true
+}
+
+// caseInsensitiveDefinitions recognises the keywords of a TokensDefinition regardless of case
+type caseInsensitiveDefinitions struct {
+	types.TokensDefinition
+	and, or, not, lbr, rbr, dquote, squote []rune
+}
+
+func (d caseInsensitiveDefinitions) IsLeftBracket(r []rune, index int) bool {
+	return startsWithFold(r, index, d.lbr)
+}
+
+func (d caseInsensitiveDefinitions) IsRightBracket(r []rune, index int) bool {
+	return startsWithFold(r, index, d.rbr)
+}
+
+func (d caseInsensitiveDefinitions) IsAnd(r []rune, index int) bool {
+	return startsWithFold(r, index, d.and)
+}
+
+func (d caseInsensitiveDefinitions) IsOr(r []rune, index int) bool {
+	return startsWithFold(r, index, d.or)
+}
+
+func (d caseInsensitiveDefinitions) IsNot(r []rune, index int) bool {
+	return startsWithFold(r, index, d.not)
+}
+
+func (d caseInsensitiveDefinitions) IsExpRune(r []rune, index int) bool {
+	return !(types.IsWhitespace(r, index) || d.IsKeyword(r, index))
+}
+
+func (d caseInsensitiveDefinitions) IsAssociativeOp(r []rune, index int) bool {
+	return d.IsOr(r, index) || d.IsAnd(r, index)
+}
+
+func (d caseInsensitiveDefinitions) IsKeyword(r []rune, index int) bool {
+	return d.IsOr(r, index) || d.IsAnd(r, index) || d.IsLeftBracket(r, index) || d.IsRightBracket(r, index) || d.IsNot(r, index)
+}
+
+func (d caseInsensitiveDefinitions) IsQuote(r []rune, index int) bool {
+	return d.IsSingleInvertedComma(r, index) || d.IsDoubleInvertedComma(r, index)
+}
+
+func (d caseInsensitiveDefinitions) IsSingleInvertedComma(r []rune, index int) bool {
+	return startsWithFold(r, index, d.squote)
+}
+
+func (d caseInsensitiveDefinitions) IsDoubleInvertedComma(r []rune, index int) bool {
+	return startsWithFold(r, index, d.dquote)
+}
+
+// startsWithFold returns true if r has key at index, ignoring case.
+// Like the lexer, an empty key is found everywhere.
+func startsWithFold(r []rune, index int, key []rune) bool {
+	if index+len(key) > len(r) {
+		return false
+	}
+	for i, k := range key {
+		if r[index+i] != k && !strings.EqualFold(string(r[index+i]), string(k)) {
+			return false
+		}
+	}
+	return true
+}
+
+// escapeRune starts an escape sequence, see stoctypes.ESCAPE of stoc
+const escapeRune = '\\'
+
+// escapeDefinitions recognises the keywords of the definitions it wraps, except where they are escaped.
+// Escaped runes are always part of an expression.
+type escapeDefinitions struct {
+	lexer.Definitions
+}
+
+func (d escapeDefinitions) IsLeftBracket(r []rune, index int) bool {
+	return !isEscaped(r, index) && d.Definitions.IsLeftBracket(r, index)
+}
+
+func (d escapeDefinitions) IsRightBracket(r []rune, index int) bool {
+	return !isEscaped(r, index) && d.Definitions.IsRightBracket(r, index)
+}
+
+func (d escapeDefinitions) IsAnd(r []rune, index int) bool {
+	return !isEscaped(r, index) && d.Definitions.IsAnd(r, index)
+}
+
+func (d escapeDefinitions) IsOr(r []rune, index int) bool {
+	return !isEscaped(r, index) && d.Definitions.IsOr(r, index)
+}
+
+func (d escapeDefinitions) IsNot(r []rune, index int) bool {
+	return !isEscaped(r, index) && d.Definitions.IsNot(r, index)
+}
+
+func (d escapeDefinitions) IsExpRune(r []rune, index int) bool {
+	return isEscaped(r, index) || d.Definitions.IsExpRune(r, index)
+}
+
+func (d escapeDefinitions) IsAssociativeOp(r []rune, index int) bool {
+	return !isEscaped(r, index) && d.Definitions.IsAssociativeOp(r, index)
+}
+
+func (d escapeDefinitions) IsKeyword(r []rune, index int) bool {
+	return !isEscaped(r, index) && d.Definitions.IsKeyword(r, index)
+}
+
+func (d escapeDefinitions) IsQuote(r []rune, index int) bool {
+	return !isEscaped(r, index) && d.Definitions.IsQuote(r, index)
+}
+
+func (d escapeDefinitions) IsSingleInvertedComma(r []rune, index int) bool {
+	return !isEscaped(r, index) && d.Definitions.IsSingleInvertedComma(r, index)
+}
+
+func (d escapeDefinitions) IsDoubleInvertedComma(r []rune, index int) bool {
+	return !isEscaped(r, index) && d.Definitions.IsDoubleInvertedComma(r, index)
+}
+
+// isEscaped returns true if the rune of r at index follows an escape, which is an odd number of escape runes
+func isEscaped(r []rune, index int) bool {
+	escapes := 0
+	for i := index - 1; i >= 0 && i < len(r) && r[i] == escapeRune; i-- {
+		escapes++
+	}
+	return escapes%2 == 1
+}
+
+// validateEscapes checks that every escape sequence in raw is complete, returning an error positioned at the first
+// that is not
+func validateEscapes(raw []rune) pos_error.PosError {
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != escapeRune {
+			continue
+		}
+		if i+1 == len(raw) {
+			return pos_error.New("escape error, \\ must be followed by a character", i)
+		}
+		if raw[i+1] == 'u' {
+			r, ok := unicodeEscape(raw, i+2)
+			if !ok {
+				return pos_error.New("escape error, \\u must be followed by four hexadecimal digits", i)
+			} else if utf16.IsSurrogate(r) {
+				return pos_error.New("escape error, \\u"+string(raw[i+2:i+6])+" is a surrogate, not a character", i)
+			}
+		}
+		i++
+	}
+	return nil
+}
+
+// unescape applies the escape sequences of exp, which have been validated with validateEscapes
+func unescape(exp string) string {
+	if !strings.ContainsRune(exp, escapeRune) {
+		return exp
+	}
+
+	raw := []rune(exp)
+	var sb strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != escapeRune || i+1 == len(raw) {
+			sb.WriteRune(raw[i])
+			continue
+		}
+		i++
+		switch raw[i] {
+		case 'n':
+			sb.WriteRune('\n')
+		case 't':
+			sb.WriteRune('\t')
+		case 'u':
+			if r, ok := unicodeEscape(raw, i+1); ok {
+				sb.WriteRune(r)
+				i += 4
+			} else {
+				sb.WriteRune(raw[i])
+			}
+		default:
+			sb.WriteRune(raw[i])
+		}
+	}
+	return sb.String()
+}
+
+// unicodeEscape reads the four hexadecimal digits of a \uXXXX escape sequence at index of raw
+func unicodeEscape(raw []rune, index int) (rune, bool) {
+	if index+4 > len(raw) {
+		return 0, false
+	}
+	code, err := strconv.ParseUint(string(raw[index:index+4]), 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return rune(code), true
+}
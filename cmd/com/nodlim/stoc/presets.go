@@ -0,0 +1,102 @@
+package stoc
+
+import (
+	"errors"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	stoctypes "github.com/kranzuft/stoc/cmd/com/nodlim/stoc/types"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// presets are the registered syntaxes, by name
+var presets = struct {
+	sync.RWMutex
+	byName map[string]types.TokensDefinition
+}{byName: map[string]types.TokensDefinition{
+	"symbolic": types.DefaultTokensDefinition,
+	"english":  wordsPreset("and", "or", "not", "{", "}"),
+	"sql":      withOption(wordsPreset("AND", "OR", "NOT", "(", ")"), stoctypes.CASE_INSENSITIVE, "case insensitive keywords"),
+	"c":        wordsPreset("&&", "||", "!", "(", ")"),
+	"german":   wordsPreset("und", "oder", "nicht", "(", ")"),
+	"french":   wordsPreset("et", "ou", "non", "(", ")"),
+	"spanish":  wordsPreset("y", "o", "no", "(", ")"),
+}}
+
+// wordsPreset defines a syntax with the given keywords, and the usual quotes
+func wordsPreset(and string, or string, not string, left string, right string) types.TokensDefinition {
+	def := stoctypes.Definition{}
+	return def.DefineTokenInfo(types.AND, and, "and").
+		DefineTokenInfo(types.OR, or, "or").
+		DefineTokenInfo(types.NOT, not, "not").
+		DefineTokenInfo(types.ANDNOT, and+" "+not, "and not").
+		DefineTokenInfo(types.ORNOT, or+" "+not, "or not").
+		DefineTokenInfo(types.TRUE, "True", "true").
+		DefineTokenInfo(types.LBR, left, "left bracket").
+		DefineTokenInfo(types.RBR, right, "right bracket").
+		DefineTokenInfo(types.EOL, "\n", "end of line").
+		DefineTokenInfo(types.EXP, "", "expression").
+		DefineTokenInfo(types.DQUOTE, "\"", "double inverted comma").
+		DefineTokenInfo(types.SQUOTE, "'", "single inverted comma").
+		Finalise()
+}
+
+// Preset returns a copy of the syntax registered with name, and whether there is one, so the preset is not changed by
+// changing the copy. The built in presets are:
+//   - symbolic: the default syntax, types.DefaultTokensDefinition, such as lazy & !(fox | dog)
+//   - english: lazy and not {fox or dog}
+//   - sql: lazy AND NOT (fox OR dog), with keywords in any case, as lazy and not (fox or dog)
+//   - c: lazy && !(fox || dog)
+//   - german: lazy und nicht (fox oder dog)
+//   - french: lazy et non (fox ou dog)
+//   - spanish: 'lazy' y no ('fox' o 'dog'), quoting the expressions as lazy, fox and dog contain y or o
+//
+// Keywords are found anywhere in a condition, even inside words, so expressions containing them must be quoted:
+// 'android' in the english syntax, for instance.
+func Preset(name string) (types.TokensDefinition, bool) {
+	presets.RLock()
+	defer presets.RUnlock()
+	defs, ok := presets.byName[name]
+	if !ok {
+		return nil, false
+	}
+	return copyTokensDefinition(defs), true
+}
+
+// PresetNames returns the names of every registered syntax, in order
+func PresetNames() []string {
+	presets.RLock()
+	defer presets.RUnlock()
+	names := make([]string, 0, len(presets.byName))
+	for name := range presets.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RegisterPreset registers a copy of defs as a syntax that can be retrieved with Preset, such as one read with
+// LoadTokensDefinition. An error is returned if defs is not valid, see ValidateTokensDefinition,
+// or if a syntax is already registered with name.
+func RegisterPreset(name string, defs types.TokensDefinition) error {
+	if err := ValidateTokensDefinition(defs); err != nil {
+		return err
+	}
+
+	presets.Lock()
+	defer presets.Unlock()
+	if _, ok := presets.byName[name]; ok {
+		return errors.New("cannot register syntax, a syntax is already registered as " + strconv.Quote(name))
+	}
+	presets.byName[name] = copyTokensDefinition(defs)
+	return nil
+}
+
+// copyTokensDefinition copies defs, so that the copy can be changed independently
+func copyTokensDefinition(defs types.TokensDefinition) types.TokensDefinition {
+	copied := make(types.TokensDefinition, len(defs))
+	for typ, info := range defs {
+		copied[typ] = info
+	}
+	return copied
+}
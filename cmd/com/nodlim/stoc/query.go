@@ -0,0 +1,116 @@
+package stoc
+
+import (
+	"errors"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+)
+
+// Query is a condition built in code rather than written as text, for instance:
+//
+//	Term("foo").And(Not(Term("bar").Or(Term("baz"))))
+//
+// Expressions are taken literally, so terms from user data need no quoting or escaping.
+// Queries are immutable, every method returns a new Query and a Query can be reused in many others.
+// The zero Query is not a valid condition, and is ignored when joined to another Query.
+type Query struct {
+	root *node
+}
+
+// Term creates a query for an expression, which is met if the target contains exp
+func Term(exp string) Query {
+	return Query{root: &node{tok: types.Token{Typ: types.EXP, Exp: exp}}}
+}
+
+// Not creates a query that is met if q is not met
+func Not(q Query) Query {
+	if q.root == nil {
+		return q
+	}
+	return Query{root: &node{tok: types.Token{Typ: types.ANDNOT, Exp: "!"}, left: trueNode(), right: q.root}}
+}
+
+// AllOf creates a query that is met if every one of queries is met.
+// With no queries, the result is the empty expression, which is met by every target.
+func AllOf(queries ...Query) Query {
+	if len(queries) == 0 {
+		return Term("")
+	}
+	return chain(Query.And, queries)
+}
+
+// AnyOf creates a query that is met if any one of queries is met.
+// With no queries, the result is the inverted empty expression, which is never met.
+func AnyOf(queries ...Query) Query {
+	if len(queries) == 0 {
+		return Not(Term(""))
+	}
+	return chain(Query.Or, queries)
+}
+
+// And creates a query that is met if both q and other are met
+func (q Query) And(other Query) Query {
+	if other.root != nil && other.root.isNegation() {
+		return q.AndNot(Query{root: other.root.right})
+	}
+	return q.join(types.AND, "&", other)
+}
+
+// Or creates a query that is met if either q or other is met
+func (q Query) Or(other Query) Query {
+	if other.root != nil && other.root.isNegation() {
+		return q.OrNot(Query{root: other.root.right})
+	}
+	return q.join(types.OR, "|", other)
+}
+
+// AndNot creates a query that is met if q is met and other is not
+func (q Query) AndNot(other Query) Query {
+	return q.join(types.ANDNOT, "&!", other)
+}
+
+// OrNot creates a query that is met if q is met or other is not
+func (q Query) OrNot(other Query) Query {
+	return q.join(types.ORNOT, "|!", other)
+}
+
+// Tokens compiles the query into postfix tokens, the same tokens LexIntoTokens produces for the query written as text
+func (q Query) Tokens() PreparedTokens {
+	if q.root == nil {
+		return nil
+	}
+	return q.root.postfix()
+}
+
+// Format renders the query as a condition in the syntax defined by defs, quoting expressions as needed.
+// See the Format function.
+func (q Query) Format(defs types.TokensDefinition) (string, error) {
+	if q.root == nil {
+		return "", errors.New("cannot format, the query is empty")
+	}
+	return Format(defs, q.Tokens())
+}
+
+// join creates a query for the operator typ, with q as the left operand and other as the right operand.
+// The keyword of the operator in types.DefaultTokensDefinition is used as the token's text.
+func (q Query) join(typ types.TokenType, keyword string, other Query) Query {
+	if q.root == nil {
+		return other
+	} else if other.root == nil {
+		return q
+	}
+	return Query{root: &node{tok: types.Token{Typ: typ, Exp: keyword}, left: q.root, right: other.root}}
+}
+
+// chain joins queries from left to right using op
+func chain(op func(Query, Query) Query, queries []Query) Query {
+	result := queries[0]
+	for _, query := range queries[1:] {
+		result = op(result, query)
+	}
+	return result
+}
+
+// trueNode creates a types.TRUE leaf, as the lexer does for a not keyword
+func trueNode() *node {
+	return &node{tok: types.Token{Typ: types.TRUE, Exp: "true"}}
+}
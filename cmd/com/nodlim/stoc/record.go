@@ -0,0 +1,226 @@
+package stoc
+
+import (
+	"fmt"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/pos_error"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Fields is implemented by records that conditions can be evaluated against.
+//
+// A term with a field prefix, such as author:"kranz" or author:kranz, is only checked against the named field.
+// Any other term is checked against every text field of the record.
+// A prefix that does not name a field of the record is treated as part of the term.
+type Fields interface {
+	// Lookup returns the text of the field with the given name, and whether the record has such a field
+	Lookup(name string) (string, bool)
+	// Texts returns the text of every field that is searched by terms without a field prefix
+	Texts() []string
+}
+
+// SearchRecord will search through the fields of record based on the command arg.
+// The command string must be a valid condition.
+//
+// The condition must follow the syntax of types.DefaultTokensDefinition and match the standard condition grammar.
+//
+// Example arguments returning true: `author:kranz & !draft`, StructFields(Post{Author: "kranzuft", Body: "..."})
+func SearchRecord(command string, record Fields) (bool, pos_error.PosError) {
+	return SearchRecordCustom(types.DefaultTokensDefinition, command, record)
+}
+
+// SearchRecordCustom will search through the fields of record based on the command string.
+// The command string must be a valid condition.
+//
+// The condition must follow the syntax defined by defs arg and match the standard condition grammar.
+func SearchRecordCustom(defs types.TokensDefinition, command string, record Fields) (bool, pos_error.PosError) {
+	preparedTokens, err := LexIntoTokens(defs, command)
+
+	if err == nil {
+		return SearchTokensRecord(preparedTokens, record), err
+	}
+
+	return false, err
+}
+
+// SearchTokensRecord searches the fields of record with pre-prepared tokens
+func SearchTokensRecord(preparation PreparedTokens, record Fields) bool {
+	return evaluatePostfix(preparation, func(exp string) bool {
+		return recordContains(record, exp)
+	})
+}
+
+// FilterStructs returns the records that meet the condition of the pre-prepared tokens, in their original order.
+// Each record is adapted with StructFields.
+func FilterStructs[T any](preparation PreparedTokens, records []T) []T {
+	var result []T
+	for _, record := range records {
+		if SearchTokensRecord(preparation, StructFields(record)) {
+			result = append(result, record)
+		}
+	}
+	return result
+}
+
+// recordContains determines if the term exp is found in record, honouring any field prefix of the term
+func recordContains(record Fields, exp string) bool {
+	if field, value, ok := splitFieldTerm(exp); ok {
+		if text, found := record.Lookup(field); found {
+			return strings.Contains(text, value)
+		}
+	}
+
+	for _, text := range record.Texts() {
+		if strings.Contains(text, exp) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitFieldTerm splits a term of the form name:value into the field name and the value.
+// Quotes surrounding the value are removed, so that author:"kranz" and author:kranz are the same term.
+func splitFieldTerm(exp string) (string, string, bool) {
+	colon := strings.IndexRune(exp, ':')
+	if colon <= 0 {
+		return "", "", false
+	}
+
+	for i, r := range exp[:colon] {
+		if !(unicode.IsLetter(r) || r == '_' || (i > 0 && (unicode.IsDigit(r) || r == '.' || r == '-'))) {
+			return "", "", false
+		}
+	}
+
+	value := exp[colon+1:]
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+
+	return exp[:colon], value, true
+}
+
+// structFields adapts a struct to Fields using reflection. See StructFields.
+type structFields struct {
+	value  reflect.Value
+	fields []structField
+}
+
+// structField is a text field of a struct, as found by reflection
+type structField struct {
+	name  string
+	index []int
+}
+
+// structFieldsCache caches the text fields of each struct type, since records are usually filtered in bulk
+var structFieldsCache sync.Map
+
+// StructFields adapts v, a struct or a pointer to a struct, to the Fields interface.
+//
+// Exported fields holding a string, a []string or a fmt.Stringer are text fields; other fields are ignored.
+// Embedded structs contribute their fields as if they were declared directly.
+// A field is named by its `stoc` struct tag, or by its Go name when there is no tag. A tag of "-" skips the field.
+// Field names are matched case-insensitively, so the Go field Author can be searched with author:"kranz".
+func StructFields(v any) Fields {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return structFields{}
+		}
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return structFields{}
+	}
+
+	if cached, ok := structFieldsCache.Load(value.Type()); ok {
+		return structFields{value: value, fields: cached.([]structField)}
+	}
+
+	fields := collectStructFields(value.Type(), nil)
+	structFieldsCache.Store(value.Type(), fields)
+	return structFields{value: value, fields: fields}
+}
+
+// Lookup returns the text of the field called name, matched case-insensitively
+func (sf structFields) Lookup(name string) (string, bool) {
+	for _, field := range sf.fields {
+		if strings.EqualFold(field.name, name) {
+			text, _ := fieldText(sf.value.FieldByIndex(field.index))
+			return text, true
+		}
+	}
+	return "", false
+}
+
+// Texts returns the text of every text field in declaration order
+func (sf structFields) Texts() []string {
+	texts := make([]string, 0, len(sf.fields))
+	for _, field := range sf.fields {
+		if text, ok := fieldText(sf.value.FieldByIndex(field.index)); ok {
+			texts = append(texts, text)
+		}
+	}
+	return texts
+}
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+// collectStructFields finds the text fields of typ, descending into embedded structs
+func collectStructFields(typ reflect.Type, parent []int) []structField {
+	var fields []structField
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		index := append(append([]int{}, parent...), i)
+		tag := field.Tag.Get("stoc")
+
+		if tag == "-" {
+			continue
+		} else if field.Anonymous && field.Type.Kind() == reflect.Struct && tag == "" {
+			fields = append(fields, collectStructFields(field.Type, index)...)
+		} else if field.IsExported() && isTextType(field.Type) {
+			name := tag
+			if name == "" {
+				name = field.Name
+			}
+			fields = append(fields, structField{name: name, index: index})
+		}
+	}
+	return fields
+}
+
+// isTextType returns true if values of typ can be searched as text
+func isTextType(typ reflect.Type) bool {
+	return typ.Kind() == reflect.String ||
+		(typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.String) ||
+		typ.Implements(stringerType)
+}
+
+// fieldText gets the text of a field value. Elements of a []string are joined with new lines.
+// Returns false if the value holds no text, such as a nil fmt.Stringer.
+func fieldText(value reflect.Value) (string, bool) {
+	if value.Type().Implements(stringerType) {
+		if !value.CanInterface() || ((value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface) && value.IsNil()) {
+			return "", false
+		}
+		return value.Interface().(fmt.Stringer).String(), true
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), true
+	case reflect.Slice:
+		lines := make([]string, value.Len())
+		for i := range lines {
+			lines[i] = value.Index(i).String()
+		}
+		return strings.Join(lines, "\n"), true
+	}
+
+	return "", false
+}
@@ -0,0 +1,268 @@
+package stoc
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxPermutations is the number of orderings of a conjunction ToRegexp allows when it is given no maximum
+const DefaultMaxPermutations = 120
+
+// neverPattern is a regular expression that matches no target
+const neverPattern = `[^\x00-\x{10FFFF}]`
+
+// ErrNotRegexp is returned, wrapped with more detail, when a condition cannot be expressed as a regular expression
+var ErrNotRegexp = errors.New("cannot convert to a regular expression")
+
+// regexpError wraps ErrNotRegexp with a description of the problem
+type regexpError struct {
+	detail string
+}
+
+func (err *regexpError) Error() string {
+	return ErrNotRegexp.Error() + ", " + err.detail
+}
+
+func (err *regexpError) Unwrap() error {
+	return ErrNotRegexp
+}
+
+// ToRegexp converts pre-prepared tokens into an RE2 regular expression, as accepted by the regexp package,
+// that matches the same targets, for tools that only accept regular expressions. For instance, lazy | fox & dog is:
+//
+//	(?s)(?:(?:lazy|fox).*dog|dog.*(?:lazy|fox))
+//
+// Expressions are quoted, and disjunctions become alternations. A conjunction becomes an alternation of every
+// ordering of its operands, so the number of orderings grows quickly; conversion stops with a *LimitError of kind
+// PermutationsLimit if any conjunction has more than maxPermutations orderings.
+// A zero or negative maxPermutations means DefaultMaxPermutations.
+//
+// Regular expressions cannot say that something is not in the target, so inversions are restricted to single
+// characters that are operands of the whole condition, or of an operand of a disjunction of the whole condition,
+// such as !; or (a & !, & !;) | b. Other conditions with inversions return an error wrapping ErrNotRegexp.
+//
+// Operands of a conjunction are matched one after another, so they cannot share text in the target. Conditions where
+// they could, because an expression of one operand contains an expression of another, as in fox & foxes, or ends with
+// the start of it, as in ab & bc, which abc meets, return an error wrapping ErrNotRegexp.
+//
+// An error is returned if the tokens are not a valid postfix condition.
+func ToRegexp(preparation PreparedTokens, maxPermutations int) (string, error) {
+	tree, err := buildTree(preparation)
+	if err != nil {
+		return "", err
+	}
+
+	if maxPermutations <= 0 {
+		maxPermutations = DefaultMaxPermutations
+	}
+
+	b := regexpBuilder{maxPermutations: maxPermutations}
+	pattern, patternErr := b.whole(pushNegations(simplifyExpr(toExpr(tree))))
+	if patternErr != nil {
+		return "", patternErr
+	}
+	return "(?s)" + pattern, nil
+}
+
+// regexpBuilder converts expressions into regular expressions
+type regexpBuilder struct {
+	// maxPermutations is the maximum number of orderings of a conjunction
+	maxPermutations int
+}
+
+// whole converts e into a regular expression for the whole target, where inversions of characters can be anchored
+func (b regexpBuilder) whole(e *expr) (string, error) {
+	switch e.kind {
+	case orExpr:
+		alternatives := make([]string, len(e.args))
+		for i, arg := range e.args {
+			alternative, err := b.whole(arg)
+			if err != nil {
+				return "", err
+			}
+			alternatives[i] = alternative
+		}
+		return strings.Join(alternatives, "|"), nil
+	case notExpr, andExpr:
+	default:
+		return b.part(e, nil)
+	}
+
+	conjuncts := []*expr{e}
+	if e.kind == andExpr {
+		conjuncts = e.args
+	}
+
+	var excluded []rune
+	var positives []*expr
+	for _, conjunct := range conjuncts {
+		if conjunct.kind != notExpr {
+			positives = append(positives, conjunct)
+			continue
+		}
+
+		term := conjunct.args[0].term
+		if term == "" {
+			// the empty expression is in every target
+			return neverPattern, nil
+		} else if utf8.RuneCountInString(term) != 1 {
+			return "", &regexpError{"the inversion of " + quoteTerm(term) + " is not of a single character"}
+		}
+		excluded = append(excluded, []rune(term)[0])
+	}
+
+	if len(excluded) == 0 {
+		return b.part(e, nil)
+	}
+
+	gap := gapPattern(excluded)
+	if len(positives) == 0 {
+		return "^" + gap + "$", nil
+	}
+	body, err := b.conjunction(positives, excluded)
+	if err != nil {
+		return "", err
+	}
+	return "^" + gap + body + gap + "$", nil
+}
+
+// part converts e into a regular expression for part of the target, where none of the excluded characters can be
+func (b regexpBuilder) part(e *expr, excluded []rune) (string, error) {
+	switch e.kind {
+	case termExpr:
+		if strings.ContainsAny(e.term, string(excluded)) {
+			return neverPattern, nil
+		}
+		return regexp.QuoteMeta(e.term), nil
+	case trueExpr:
+		return "", nil
+	case falseExpr:
+		return neverPattern, nil
+	case notExpr:
+		return "", &regexpError{"the inversion of " + quoteTerm(e.args[0].term) + " is not an operand of the whole condition"}
+	case andExpr:
+		return b.conjunction(e.args, excluded)
+	default: // orExpr
+		alternatives := make([]string, len(e.args))
+		for i, arg := range e.args {
+			alternative, err := b.part(arg, excluded)
+			if err != nil {
+				return "", err
+			}
+			alternatives[i] = alternative
+		}
+		return "(?:" + strings.Join(alternatives, "|") + ")", nil
+	}
+}
+
+// conjunction converts operands that must all be found into an alternation of every ordering of them
+func (b regexpBuilder) conjunction(operands []*expr, excluded []rune) (string, error) {
+	parts := make([]string, len(operands))
+	for i, operand := range operands {
+		part, err := b.part(operand, excluded)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = part
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+
+	for i, operand := range operands {
+		for _, other := range operands[i+1:] {
+			if a, b, ok := overlappingTerms(operand, other); ok {
+				return "", &regexpError{"the expressions " + quoteTerm(a) + " and " + quoteTerm(b) + " of a conjunction can overlap"}
+			}
+		}
+	}
+
+	if count := factorial(len(parts)); count > b.maxPermutations {
+		return "", &LimitError{Kind: PermutationsLimit, Max: b.maxPermutations, Actual: count}
+	}
+
+	gap := gapPattern(excluded)
+	var orderings []string
+	permute(parts, 0, func(ordering []string) {
+		orderings = append(orderings, strings.Join(ordering, gap))
+	})
+	return "(?:" + strings.Join(orderings, "|") + ")", nil
+}
+
+// overlappingTerms finds an expression of a and an expression of b that can share text in a target, where one contains
+// the other or one ends with the start of the other
+func overlappingTerms(a *expr, b *expr) (string, string, bool) {
+	for _, termA := range a.terms() {
+		for _, termB := range b.terms() {
+			if termA == "" || termB == "" {
+				continue
+			}
+			if strings.Contains(termA, termB) || strings.Contains(termB, termA) ||
+				endsWithStartOf(termA, termB) || endsWithStartOf(termB, termA) {
+				return termA, termB, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// endsWithStartOf returns true if a proper suffix of a is a prefix of b
+func endsWithStartOf(a string, b string) bool {
+	for i := range a {
+		if i > 0 && strings.HasPrefix(b, a[i:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// gapPattern matches any text between operands of a conjunction, without any of the excluded characters
+func gapPattern(excluded []rune) string {
+	if len(excluded) == 0 {
+		return ".*"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("[^")
+	for _, r := range excluded {
+		if strings.ContainsRune(`\]^-[`, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	sb.WriteString("]*")
+	return sb.String()
+}
+
+// permute calls visit with every ordering of items, in lexicographic order of their positions.
+// items is reordered while permuting and restored afterwards.
+func permute(items []string, k int, visit func([]string)) {
+	if k == len(items) {
+		visit(items)
+		return
+	}
+	for i := k; i < len(items); i++ {
+		// rotate item i to position k, keeping the rest in order
+		moved := items[i]
+		copy(items[k+1:i+1], items[k:i])
+		items[k] = moved
+		permute(items, k+1, visit)
+		copy(items[k:i], items[k+1:i+1])
+		items[i] = moved
+	}
+}
+
+// factorial returns n!, or the largest int if n! is larger
+func factorial(n int) int {
+	result := 1
+	for i := 2; i <= n; i++ {
+		if result > math.MaxInt/i {
+			return math.MaxInt
+		}
+		result *= i
+	}
+	return result
+}
@@ -59,12 +59,13 @@ func SearchTokens(preparation PreparedTokens, target string) bool {
 	return SearchPostfixTokens(preparation, target)
 }
 
-// LexIntoTokens produces postfix tokens from TokensDefinition and raw tokens-to-be command
+// LexIntoTokens produces postfix tokens from TokensDefinition and raw tokens-to-be command.
+// Options defined in defs, such as the options of the stoc types package, change how the command is lexed.
 func LexIntoTokens(defs types.TokensDefinition, command string) (PreparedTokens, pos_error.PosError) {
-	tokens, errLex := lexer.BooleanAlgebraLexer(defs, []rune(command))
+	lexed, errLex := lexCondition(defs, definitions(defs), []rune(command))
 
 	if errLex == nil {
-		result, errShunt := lexer.TokenShuntingAlgorithm(tokens)
+		result, errShunt := lexer.TokenShuntingAlgorithm(lexed.tokens)
 
 		if errShunt == nil {
 			return result, errShunt
@@ -80,7 +81,16 @@ func LexIntoTokens(defs types.TokensDefinition, command string) (PreparedTokens,
 // the 'target' string. If the conditions aren't met then
 // Recommended to not be called directly. Instead, call stoc.SearchString or stoc.SearchStringCustom.
 func SearchPostfixTokens(search []types.Token, target string) bool {
-	var stack []bool
+	return evaluatePostfix(search, func(exp string) bool {
+		return strings.Contains(target, exp)
+	})
+}
+
+// evaluatePostfix evaluates the postfix formatted tokens, using matches to determine if each expression is found
+func evaluatePostfix(search []types.Token, matches func(exp string) bool) bool {
+	// the stack starts in a fixed buffer so that typical conditions are evaluated without allocating
+	var buffer [32]bool
+	stack := buffer[:0]
 	for _, tok := range search {
 		// action := "Apply op to top of stack"
 		switch tok.Typ {
@@ -103,7 +113,7 @@ func SearchPostfixTokens(search []types.Token, target string) bool {
 		default:
 			// action = "Push num onto top of stack"
 			if tok.Typ != types.TRUE {
-				stack = append(stack, strings.Contains(target, tok.Exp))
+				stack = append(stack, matches(tok.Exp))
 			} else {
 				stack = append(stack, true)
 			}
@@ -0,0 +1,186 @@
+package stoc
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Simplify rewrites pre-prepared tokens into an equivalent condition that is no larger, by applying:
+//   - double inversion removal: !(!a) is a
+//   - idempotence: a & a and a | a are a
+//   - absorption: a & (a | b) and a | (a & b) are a
+//   - complements: a & !a is never met, a | !a is always met
+//   - constant folding of types.TRUE, and anything always or never met
+//
+// Conditions that are always met simplify to a lone types.TRUE, and conditions that are never met simplify to
+// its inversion. For full boolean minimisation see Minimise.
+//
+// An error is returned if the tokens are not a valid postfix condition.
+func Simplify(preparation PreparedTokens) (PreparedTokens, error) {
+	tree, err := buildTree(preparation)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromExpr(simplifyExpr(toExpr(tree))).Tokens(), nil
+}
+
+// simplifyExpr applies the rules of Simplify to e until it can no longer be made smaller
+func simplifyExpr(e *expr) *expr {
+	for {
+		simplified := simplifyOnce(e)
+		if simplified.key() == e.key() {
+			return simplified
+		}
+		e = simplified
+	}
+}
+
+// simplifyOnce applies the rules of Simplify to e and all of its operands, bottom up
+func simplifyOnce(e *expr) *expr {
+	switch e.kind {
+	case notExpr:
+		return notOf(simplifyOnce(e.args[0]))
+	case andExpr, orExpr:
+	default:
+		return e
+	}
+
+	operands := make([]*expr, len(e.args))
+	for i, arg := range e.args {
+		operands[i] = simplifyOnce(arg)
+	}
+
+	joined := joinExpr(e.kind, operands...)
+	if joined.kind != e.kind {
+		return joined
+	}
+
+	identity, absorbing := trueExpr, falseExpr
+	inner := orExpr
+	if e.kind == orExpr {
+		identity, absorbing, inner = falseExpr, trueExpr, andExpr
+	}
+
+	keys := make(map[string]bool, len(joined.args))
+	for _, arg := range joined.args {
+		keys[arg.key()] = true
+	}
+
+	var kept []*expr
+	seen := map[string]bool{}
+	for _, arg := range joined.args {
+		key := arg.key()
+		if seen[key] {
+			// idempotence
+			continue
+		}
+		seen[key] = true
+
+		if keys[notOf(arg).key()] {
+			// complements
+			return &expr{kind: absorbing}
+		}
+
+		if arg.kind == inner && absorbed(arg, joined.args, inner) {
+			// absorption
+			continue
+		}
+
+		kept = append(kept, arg)
+	}
+
+	if len(kept) == 0 {
+		return &expr{kind: identity}
+	}
+	return joinExpr(e.kind, kept...)
+}
+
+// absorbed returns true if y, an operand of kind inner, is absorbed by one of its siblings.
+// A sibling absorbs y if all the operands of the sibling are operands of y, as a is in a & (a | b),
+// or a | b is in (a | b) & (a | b | c).
+func absorbed(y *expr, siblings []*expr, inner exprKind) bool {
+	operands := make(map[string]bool, len(y.args))
+	for _, arg := range y.args {
+		operands[arg.key()] = true
+	}
+
+	for _, x := range siblings {
+		if x == y || x.key() == y.key() {
+			continue
+		}
+
+		subset := []*expr{x}
+		if x.kind == inner {
+			subset = x.args
+		}
+
+		contained := true
+		for _, arg := range subset {
+			contained = contained && operands[arg.key()]
+		}
+		if contained {
+			return true
+		}
+	}
+	return false
+}
+
+// key returns a canonical text for e, equal for expressions that differ only in the order of operands
+func (e *expr) key() string {
+	switch e.kind {
+	case termExpr:
+		return strconv.Quote(e.term)
+	case trueExpr:
+		return "T"
+	case falseExpr:
+		return "F"
+	case notExpr:
+		return "!" + e.args[0].key()
+	}
+
+	keys := make([]string, len(e.args))
+	for i, arg := range e.args {
+		keys[i] = arg.key()
+	}
+	sort.Strings(keys)
+
+	op := "&"
+	if e.kind == orExpr {
+		op = "|"
+	}
+	return "(" + strings.Join(keys, op) + ")"
+}
+
+// fromExpr converts e into a Query, which compiles it into tokens the same way the lexer would.
+// Inverted operands of conjunctions and disjunctions are moved after the other operands, so that they become
+// complex operators rather than needing a types.TRUE.
+func fromExpr(e *expr) Query {
+	switch e.kind {
+	case termExpr:
+		return Term(e.term)
+	case trueExpr:
+		return Query{root: trueNode()}
+	case falseExpr:
+		return Not(Query{root: trueNode()})
+	case notExpr:
+		return Not(fromExpr(e.args[0]))
+	}
+
+	join := Query.And
+	if e.kind == orExpr {
+		join = Query.Or
+	}
+
+	operands := append([]*expr{}, e.args...)
+	sort.SliceStable(operands, func(i, j int) bool {
+		return operands[i].kind != notExpr && operands[j].kind == notExpr
+	})
+
+	result := fromExpr(operands[0])
+	for _, arg := range operands[1:] {
+		result = join(result, fromExpr(arg))
+	}
+	return result
+}
@@ -0,0 +1,144 @@
+package stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/lexer"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/pos_error"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+)
+
+// span is the rune positions in a condition where a token or sub-expression starts and ends (exclusive)
+type span struct {
+	start int
+	end   int
+}
+
+// sourceTree is a condition in tree form that remembers where in the condition text each node came from
+type sourceTree struct {
+	root *node
+	// raw is the condition text
+	raw []rune
+	// spans are the positions of each node, including any brackets around it. Synthesised types.TRUE nodes span
+	// the not keyword that produced them.
+	spans map[*node]span
+	// operators are the positions of the operator keywords of each operator node
+	operators map[*node]span
+	// quoted records the expressions that were written with quotes
+	quoted map[*node]bool
+}
+
+// text returns the condition text of n
+func (st *sourceTree) text(n *node) string {
+	s := st.spans[n]
+	return string(st.raw[s.start:s.end])
+}
+
+// parseSource lexes condition into a tree, recording where each node is in the condition
+func parseSource(defs types.TokensDefinition, condition string) (*sourceTree, pos_error.PosError) {
+	raw := []rune(condition)
+	lex := definitions(defs)
+	lexed, err := lexCondition(defs, lex, raw)
+	if err != nil {
+		return nil, err
+	}
+	tokens := lexed.tokens
+
+	// the shunting algorithm validates the brackets, so parsing below can assume they are matched
+	if _, err = lexer.TokenShuntingAlgorithm(tokens); err != nil {
+		return nil, err
+	}
+
+	spans, quoted := locateTokens(defs, lex, lexed.raw, lexed.written)
+	for i, s := range spans {
+		spans[i] = span{lexed.origin(s.start), lexed.origin(s.end)}
+	}
+	p := &infixParser{tokens: tokens, spans: spans, quoted: quoted}
+	st := &sourceTree{raw: raw, spans: map[*node]span{}, operators: map[*node]span{}, quoted: map[*node]bool{}}
+	st.root = p.condition(st)
+	return st, nil
+}
+
+// locateTokens finds the span of each infix token in raw, and which expression tokens were quoted.
+// Tokens are found in order, as the lexer produced them.
+func locateTokens(defs types.TokensDefinition, lex lexer.Definitions, raw []rune, tokens []types.Token) ([]span, []bool) {
+	spans := make([]span, len(tokens))
+	quoted := make([]bool, len(tokens))
+	cursor := 0
+
+	for i, tok := range tokens {
+		for cursor < len(raw) && types.IsWhitespace(raw, cursor) {
+			cursor++
+		}
+
+		exp := []rune(tok.Exp)
+		switch {
+		case tok.Typ == types.TRUE:
+			// the lexer creates types.TRUE before the not keyword that follows, without consuming any text
+			spans[i] = span{cursor, cursor}
+		case tok.Typ == types.EXP && lex.IsQuote(raw, cursor):
+			quote := keyOf(defs, types.DQUOTE)
+			if lex.IsSingleInvertedComma(raw, cursor) {
+				quote = keyOf(defs, types.SQUOTE)
+			}
+			size := len([]rune(quote))
+			spans[i] = span{cursor, cursor + size + len(exp) + size}
+			quoted[i] = true
+		default:
+			spans[i] = span{cursor, cursor + len(exp)}
+		}
+		cursor = spans[i].end
+	}
+
+	// synthesised types.TRUE spans the not keyword it was created for
+	for i, tok := range tokens {
+		if tok.Typ == types.TRUE && i+1 < len(tokens) {
+			spans[i] = spans[i+1]
+		}
+	}
+
+	return spans, quoted
+}
+
+// infixParser builds a tree from infix tokens. The grammar is that of the shunting algorithm:
+// all operators have equal precedence and are left associative.
+type infixParser struct {
+	tokens []types.Token
+	spans  []span
+	quoted []bool
+	next   int
+}
+
+// condition parses operands joined by operators, until a right bracket or the end of the tokens
+func (p *infixParser) condition(st *sourceTree) *node {
+	left := p.operand(st)
+	for p.next < len(p.tokens) && types.IsOp(p.tokens[p.next].Typ) {
+		op := p.tokens[p.next]
+		opSpan := p.spans[p.next]
+		p.next++
+		right := p.operand(st)
+
+		n := &node{tok: op, left: left, right: right}
+		st.spans[n] = span{st.spans[left].start, st.spans[right].end}
+		st.operators[n] = opSpan
+		left = n
+	}
+	return left
+}
+
+// operand parses an expression, a types.TRUE or a bracketed condition
+func (p *infixParser) operand(st *sourceTree) *node {
+	i := p.next
+	p.next++
+
+	if p.tokens[i].Typ == types.LBR {
+		inner := p.condition(st)
+		// skip the right bracket, and widen the span of the inner condition to include both brackets
+		st.spans[inner] = span{p.spans[i].start, p.spans[p.next].end}
+		p.next++
+		return inner
+	}
+
+	n := &node{tok: p.tokens[i]}
+	st.spans[n] = p.spans[i]
+	st.quoted[n] = p.quoted[i]
+	return n
+}
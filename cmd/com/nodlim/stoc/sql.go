@@ -0,0 +1,131 @@
+package stoc
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// SQLMatch is how ToSQL tests whether the column contains an expression
+type SQLMatch int
+
+const (
+	// LikeMatch uses column LIKE ? ESCAPE '\', which is case-sensitive in Postgres but not in SQLite,
+	// unless PRAGMA case_sensitive_like is on
+	LikeMatch SQLMatch = iota
+	// ILikeMatch uses column ILIKE ? ESCAPE '\', which is case-insensitive, for Postgres
+	ILikeMatch
+	// InstrMatch uses instr(column, ?) > 0, which is case-sensitive like searches, for SQLite and MySQL
+	InstrMatch
+	// StrposMatch uses strpos(column, ?) > 0, which is case-sensitive like searches, for Postgres
+	StrposMatch
+)
+
+// SQLOptions configures the WHERE clause ToSQL renders
+type SQLOptions struct {
+	// Column is the column, or any SQL expression, that is searched. It is inserted into the clause as is,
+	// so it must not come from users.
+	Column string
+	// Match is how the column is tested for each expression
+	Match SQLMatch
+	// Placeholder renders the placeholder of the n-th parameter, counting from 1.
+	// If it is nil, every placeholder is ?, as SQLite and MySQL use. Use DollarPlaceholder for Postgres.
+	Placeholder func(n int) string
+}
+
+// DollarPlaceholder renders placeholders as $1, $2 and so on, as Postgres uses
+func DollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// ToSQL renders pre-prepared tokens as a parameterised SQL WHERE clause, without the WHERE keyword, that is met by the
+// rows whose column contains the expressions of the condition. For instance, with InstrMatch on the column body,
+// lazy & !(fox | dog) is:
+//
+//	instr(body, ?) > 0 AND NOT (instr(body, ?) > 0 OR instr(body, ?) > 0)
+//
+// with the parameters lazy, fox and dog. Expressions are only ever passed as parameters. With LikeMatch and
+// ILikeMatch, the % and _ wildcards and the \ escape character in expressions are escaped.
+//
+// A row where the column is NULL does not contain any expression, and following SQL its inversion is not met either.
+// Search COALESCE(column, '') rather than column for such rows to be treated as empty.
+//
+// An error is returned if there is no column, or if the tokens are not a valid postfix condition.
+func ToSQL(preparation PreparedTokens, options SQLOptions) (string, []any, error) {
+	if options.Column == "" {
+		return "", nil, errors.New("cannot render SQL, there is no column to search")
+	}
+
+	tree, err := buildTree(preparation)
+	if err != nil {
+		return "", nil, err
+	}
+
+	r := sqlRenderer{options: options}
+	clause := r.render(toExpr(tree), false)
+	return clause, r.args, nil
+}
+
+// sqlRenderer renders expressions as SQL, collecting their parameters
+type sqlRenderer struct {
+	options SQLOptions
+	args    []any
+}
+
+// render renders e, in brackets if it is a conjunction or disjunction and bracket is true
+func (r *sqlRenderer) render(e *expr, bracket bool) string {
+	switch e.kind {
+	case termExpr:
+		return r.match(e.term)
+	case trueExpr:
+		return "1 = 1"
+	case falseExpr:
+		return "1 = 0"
+	case notExpr:
+		return "NOT (" + r.render(e.args[0], false) + ")"
+	}
+
+	keyword := " AND "
+	if e.kind == orExpr {
+		keyword = " OR "
+	}
+
+	operands := make([]string, len(e.args))
+	for i, arg := range e.args {
+		operands[i] = r.render(arg, true)
+	}
+
+	clause := strings.Join(operands, keyword)
+	if bracket {
+		return "(" + clause + ")"
+	}
+	return clause
+}
+
+// match renders the test for whether the column contains term, adding term as a parameter
+func (r *sqlRenderer) match(term string) string {
+	arg := term
+	if r.options.Match == LikeMatch || r.options.Match == ILikeMatch {
+		arg = "%" + escapeLike(term) + "%"
+	}
+	r.args = append(r.args, arg)
+
+	placeholder := "?"
+	if r.options.Placeholder != nil {
+		placeholder = r.options.Placeholder(len(r.args))
+	}
+
+	switch r.options.Match {
+	case ILikeMatch:
+		return r.options.Column + " ILIKE " + placeholder + ` ESCAPE '\'`
+	case InstrMatch:
+		return "instr(" + r.options.Column + ", " + placeholder + ") > 0"
+	case StrposMatch:
+		return "strpos(" + r.options.Column + ", " + placeholder + ") > 0"
+	default: // LikeMatch
+		return r.options.Column + " LIKE " + placeholder + ` ESCAPE '\'`
+	}
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern, and the escape character itself, with \
+var escapeLike = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace
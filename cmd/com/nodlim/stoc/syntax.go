@@ -0,0 +1,290 @@
+package stoc
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	stoctypes "github.com/kranzuft/stoc/cmd/com/nodlim/stoc/types"
+	"gopkg.in/yaml.v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// syntaxVersion is the version of the syntax configuration format
+const syntaxVersion = 1
+
+// requiredTokenTypes are the token types the lexer recognises by their keys, so must be defined with non-empty keys
+var requiredTokenTypes = []types.TokenType{types.AND, types.OR, types.NOT, types.LBR, types.RBR, types.DQUOTE, types.SQUOTE}
+
+// optionalTokenTypes are the other token types a syntax can define, whose keys only describe the token,
+// and the options of stoc
+var optionalTokenTypes = []types.TokenType{types.ANDNOT, types.ORNOT, types.TRUE, types.EOL, types.EXP, stoctypes.CASE_INSENSITIVE,
+	stoctypes.IMPLICIT_AND, stoctypes.ESCAPE, stoctypes.COMMENT}
+
+// ErrInvalidSyntax is returned, wrapped with more detail, when a TokensDefinition is invalid
+var ErrInvalidSyntax = errors.New("invalid syntax")
+
+// syntaxError wraps ErrInvalidSyntax with a description of the problem
+type syntaxError struct {
+	detail string
+}
+
+func (err *syntaxError) Error() string {
+	return ErrInvalidSyntax.Error() + ", " + err.detail
+}
+
+func (err *syntaxError) Unwrap() error {
+	return ErrInvalidSyntax
+}
+
+// syntaxConfig is the configuration file format of a TokensDefinition, for example in YAML:
+//
+//	version: 1
+//	tokens:
+//	  AND: and
+//	  OR: or
+//	  NOT: not
+//	  LEFT_BRACKET: '{'
+//	  RIGHT_BRACKET: '}'
+//	  DOUBLE_INVERTED_COMMA: '"'
+//	  SINGLE_INVERTED_COMMA: "'"
+//	descriptions:
+//	  AND: conjunction
+//
+// Descriptions are optional, and default to those of types.DefaultTokensDefinition.
+type syntaxConfig struct {
+	Version      int                        `json:"version" yaml:"version"`
+	Tokens       map[types.TokenType]string `json:"tokens" yaml:"tokens"`
+	Descriptions map[types.TokenType]string `json:"descriptions,omitempty" yaml:"descriptions,omitempty"`
+}
+
+// ValidateTokensDefinition checks that defs can be used to lex conditions. It returns an error wrapping
+// ErrInvalidSyntax if a required token type (and, or, not, the brackets and the quotes) has no key, or a key that is
+// not known because defs was not defined with a Definition of the stoc types package, if a key starts or ends with
+// whitespace, if a key contains a backslash when the ESCAPE option of stoc is enabled, or if one key is the same as,
+// or a prefix of, another key, which makes lexing ambiguous. Keys are compared regardless of case if the
+// CASE_INSENSITIVE option of stoc is enabled. The key of the COMMENT option of stoc, if enabled, is checked along
+// with the required keys.
+func ValidateTokensDefinition(defs types.TokensDefinition) error {
+	for _, typ := range requiredTokenTypes {
+		if _, ok := defs[typ]; !ok {
+			return &syntaxError{"missing key for " + string(typ)}
+		}
+		if key, ok := stoctypes.Key(defs, typ); !ok {
+			return &syntaxError{"unknown key for " + string(typ) + ", define the syntax with a types.Definition"}
+		} else if key == "" {
+			return &syntaxError{"missing key for " + string(typ)}
+		}
+	}
+
+	for _, typ := range requiredTokenTypes {
+		key := keyOf(defs, typ)
+		if strings.TrimFunc(key, unicode.IsSpace) != key {
+			return &syntaxError{"key " + strconv.Quote(key) + " of " + string(typ) + " starts or ends with whitespace"}
+		}
+		if hasOption(defs, stoctypes.ESCAPE) && strings.ContainsRune(key, escapeRune) {
+			return &syntaxError{"key " + strconv.Quote(key) + " of " + string(typ) + " contains the escape \\"}
+		}
+	}
+
+	fold := hasOption(defs, stoctypes.CASE_INSENSITIVE)
+	for i, a := range requiredTokenTypes {
+		for _, b := range requiredTokenTypes[i+1:] {
+			keyA, keyB := keyOf(defs, a), keyOf(defs, b)
+			if keyA == keyB {
+				return &syntaxError{"duplicate key " + strconv.Quote(keyA) + " for " + string(a) + " and " + string(b)}
+			} else if fold && strings.EqualFold(keyA, keyB) {
+				return &syntaxError{"duplicate keys regardless of case, " + strconv.Quote(keyA) + " of " + string(a) + " and " + strconv.Quote(keyB) + " of " + string(b)}
+			} else if keyHasPrefix(keyB, keyA, fold) {
+				return &syntaxError{"ambiguous keys, " + strconv.Quote(keyA) + " of " + string(a) + " is a prefix of " + strconv.Quote(keyB) + " of " + string(b)}
+			} else if keyHasPrefix(keyA, keyB, fold) {
+				return &syntaxError{"ambiguous keys, " + strconv.Quote(keyB) + " of " + string(b) + " is a prefix of " + strconv.Quote(keyA) + " of " + string(a)}
+			}
+		}
+	}
+
+	if comment := commentKey(defs); comment != nil {
+		key := string(comment)
+		if strings.TrimFunc(key, unicode.IsSpace) != key {
+			return &syntaxError{"key " + strconv.Quote(key) + " of " + string(stoctypes.COMMENT) + " starts or ends with whitespace"}
+		}
+		for _, typ := range requiredTokenTypes {
+			if other := keyOf(defs, typ); keyHasPrefix(other, key, fold) || keyHasPrefix(key, other, fold) {
+				return &syntaxError{"ambiguous keys, " + strconv.Quote(key) + " of " + string(stoctypes.COMMENT) + " and " + strconv.Quote(other) + " of " + string(typ)}
+			}
+		}
+	}
+	return nil
+}
+
+// keyHasPrefix returns true if key starts with prefix, regardless of case if fold
+func keyHasPrefix(key string, prefix string, fold bool) bool {
+	if fold {
+		return startsWithFold([]rune(key), 0, []rune(prefix))
+	}
+	return strings.HasPrefix(key, prefix)
+}
+
+// MarshalTokensDefinitionJSON encodes defs as an indented JSON configuration. See UnmarshalTokensDefinitionJSON.
+func MarshalTokensDefinitionJSON(defs types.TokensDefinition) ([]byte, error) {
+	data, err := json.MarshalIndent(toSyntaxConfig(defs), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// UnmarshalTokensDefinitionJSON decodes a TokensDefinition from a JSON configuration, for example:
+//
+//	{"version": 1, "tokens": {"AND": "and", "OR": "or", "NOT": "not", "LEFT_BRACKET": "{", "RIGHT_BRACKET": "}",
+//	  "DOUBLE_INVERTED_COMMA": "\"", "SINGLE_INVERTED_COMMA": "'"}}
+//
+// Token types are named as in the types package, and each has its key. An optional "descriptions" object has the
+// description of any token types, which appear in error messages. The decoded definition is validated with
+// ValidateTokensDefinition.
+func UnmarshalTokensDefinitionJSON(data []byte) (types.TokensDefinition, error) {
+	var config syntaxConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	return config.tokensDefinition()
+}
+
+// MarshalTokensDefinitionYAML encodes defs as a YAML configuration. See UnmarshalTokensDefinitionYAML.
+func MarshalTokensDefinitionYAML(defs types.TokensDefinition) ([]byte, error) {
+	return yaml.Marshal(toSyntaxConfig(defs))
+}
+
+// UnmarshalTokensDefinitionYAML decodes a TokensDefinition from a YAML configuration, in the same form as
+// UnmarshalTokensDefinitionJSON, for example:
+//
+//	version: 1
+//	tokens:
+//	  AND: and
+//	  OR: or
+//	  NOT: not
+//	  LEFT_BRACKET: '{'
+//	  RIGHT_BRACKET: '}'
+//	  DOUBLE_INVERTED_COMMA: '"'
+//	  SINGLE_INVERTED_COMMA: "'"
+func UnmarshalTokensDefinitionYAML(data []byte) (types.TokensDefinition, error) {
+	var config syntaxConfig
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, err
+	}
+	return config.tokensDefinition()
+}
+
+// LoadTokensDefinition reads a TokensDefinition from a JSON or YAML configuration file,
+// chosen by the extension of path: .json, .yaml or .yml
+func LoadTokensDefinition(path string) (types.TokensDefinition, error) {
+	unmarshal, err := syntaxFormat(path, UnmarshalTokensDefinitionJSON, UnmarshalTokensDefinitionYAML)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshal(data)
+}
+
+// SaveTokensDefinition writes defs to a JSON or YAML configuration file, chosen by the extension of path as for
+// LoadTokensDefinition. defs is validated first, so that only loadable configurations are saved.
+func SaveTokensDefinition(path string, defs types.TokensDefinition) error {
+	marshal, err := syntaxFormat(path, MarshalTokensDefinitionJSON, MarshalTokensDefinitionYAML)
+	if err != nil {
+		return err
+	}
+
+	if err := ValidateTokensDefinition(defs); err != nil {
+		return err
+	}
+
+	data, err := marshal(defs)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// syntaxFormat chooses the JSON or YAML variant of a function by the extension of path
+func syntaxFormat[F any](path string, jsonVariant F, yamlVariant F) (F, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return jsonVariant, nil
+	case ".yaml", ".yml":
+		return yamlVariant, nil
+	}
+	var none F
+	return none, errors.New("unknown syntax configuration format " + strconv.Quote(filepath.Ext(path)) + ", expected .json, .yaml or .yml")
+}
+
+// toSyntaxConfig converts defs into its configuration, with every token type it defines
+func toSyntaxConfig(defs types.TokensDefinition) syntaxConfig {
+	config := syntaxConfig{Version: syntaxVersion, Tokens: map[types.TokenType]string{}, Descriptions: map[types.TokenType]string{}}
+	for typ := range defs {
+		if stoctypes.IsKeyRecord(typ) {
+			continue
+		}
+		config.Tokens[typ] = keyOf(defs, typ)
+		if desc := defs.TokToString(typ); desc != types.DefaultTokensDefinition.TokToString(typ) {
+			config.Descriptions[typ] = desc
+		}
+	}
+	return config
+}
+
+// tokensDefinition converts the configuration into a validated TokensDefinition
+func (config syntaxConfig) tokensDefinition() (types.TokensDefinition, error) {
+	if config.Version != syntaxVersion {
+		return nil, &syntaxError{"unsupported version " + strconv.Itoa(config.Version)}
+	}
+
+	known := map[types.TokenType]bool{}
+	for _, typ := range append(append([]types.TokenType{}, requiredTokenTypes...), optionalTokenTypes...) {
+		known[typ] = true
+	}
+	for _, typ := range sortedTokenTypes(config.Tokens) {
+		if !known[typ] {
+			return nil, &syntaxError{"unknown token type " + strconv.Quote(string(typ))}
+		}
+	}
+	for _, typ := range sortedTokenTypes(config.Descriptions) {
+		if _, ok := config.Tokens[typ]; !ok {
+			return nil, &syntaxError{"description of " + string(typ) + " which has no key"}
+		}
+	}
+
+	def := stoctypes.Definition{}
+	for typ, key := range config.Tokens {
+		desc, ok := config.Descriptions[typ]
+		if !ok {
+			desc = types.DefaultTokensDefinition.TokToString(typ)
+		}
+		def.DefineTokenInfo(typ, key, desc)
+	}
+
+	defs := def.Finalise()
+	if err := ValidateTokensDefinition(defs); err != nil {
+		return nil, err
+	}
+	return defs, nil
+}
+
+// sortedTokenTypes returns the token types of m in order, so that errors do not depend on the order of the map
+func sortedTokenTypes(m map[types.TokenType]string) []types.TokenType {
+	typs := make([]types.TokenType, 0, len(m))
+	for typ := range m {
+		typs = append(typs, typ)
+	}
+	sort.Slice(typs, func(i, j int) bool {
+		return typs[i] < typs[j]
+	})
+	return typs
+}
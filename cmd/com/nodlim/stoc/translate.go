@@ -0,0 +1,27 @@
+package stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+)
+
+// Translate re-writes condition from the syntax defined by fromDefs into the syntax defined by toDefs.
+//
+// Expressions that contain keywords of toDefs are quoted, so the translated condition means the same as the original.
+// The condition is normalised on the way, see Format.
+//
+// toDefs must be defined with a Definition of the stoc types package, unless it is types.DefaultTokensDefinition,
+// see Format.
+//
+// The error is a pos_error.PosError if condition is not valid in the syntax of fromDefs,
+// otherwise it is an error from Format, such as an expression that cannot be quoted in the syntax of toDefs.
+//
+// Example: Translate(types.DefaultTokensDefinition, words, "dog | (cat & !'sandwich')") returns
+// "dog or {cat and not \"sandwich\"}", where words defines and, or, not and curly brackets.
+func Translate(fromDefs types.TokensDefinition, toDefs types.TokensDefinition, condition string) (string, error) {
+	preparedTokens, err := LexIntoTokens(fromDefs, condition)
+	if err != nil {
+		return "", err
+	}
+
+	return Format(toDefs, preparedTokens)
+}
@@ -0,0 +1,67 @@
+package stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/pos_error"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+)
+
+// node is a condition in tree form, as described by postfix tokens.
+// Leaves are expressions (types.EXP) or types.TRUE, all other nodes are binary operators.
+type node struct {
+	tok   types.Token
+	left  *node
+	right *node
+}
+
+// isLeaf returns true if the node is an expression or types.TRUE
+func (n *node) isLeaf() bool {
+	return n.left == nil && n.right == nil
+}
+
+// isNegation returns true if the node is a types.TRUE and-not another node, which is how the lexer represents a
+// not keyword at the front of a condition or after a left bracket
+func (n *node) isNegation() bool {
+	return n.tok.Typ == types.ANDNOT && n.left.tok.Typ == types.TRUE && n.left.isLeaf()
+}
+
+// buildTree converts postfix tokens into a tree.
+//
+// An error is returned if the tokens are not a valid postfix condition, with the position of the offending token.
+func buildTree(postfix []types.Token) (*node, pos_error.PosError) {
+	var stack []*node
+	for i, tok := range postfix {
+		switch {
+		case types.IsOp(tok.Typ):
+			if len(stack) < 2 {
+				return nil, pos_error.New("invalid tokens, "+string(tok.Typ)+" is missing an operand", i)
+			}
+			n := &node{tok: tok, left: stack[len(stack)-2], right: stack[len(stack)-1]}
+			stack = append(stack[:len(stack)-2], n)
+		case tok.Typ == types.EXP || tok.Typ == types.TRUE:
+			stack = append(stack, &node{tok: tok})
+		default:
+			return nil, pos_error.New("invalid tokens, unexpected "+string(tok.Typ), i)
+		}
+	}
+
+	if len(stack) != 1 {
+		return nil, pos_error.New("invalid tokens, expected a single condition", len(postfix))
+	}
+
+	return stack[0], nil
+}
+
+// postfix converts the tree back into postfix tokens
+func (n *node) postfix() PreparedTokens {
+	var tokens PreparedTokens
+	var walk func(*node)
+	walk = func(cur *node) {
+		if !cur.isLeaf() {
+			walk(cur.left)
+			walk(cur.right)
+		}
+		tokens = append(tokens, cur.tok)
+	}
+	walk(n)
+	return tokens
+}
@@ -0,0 +1,171 @@
+package stoc
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTruthTableTerms is the largest number of distinct expressions TruthTable will enumerate.
+// Each expression doubles the number of rows, so 16 expressions is 65536 rows.
+const maxTruthTableTerms = 16
+
+// Table is the truth table of a condition, with a row for every combination of its expressions being found
+type Table struct {
+	// Terms are the distinct expressions of the condition, in the order they first appear
+	Terms []string
+	// Rows are the combinations, starting with every expression found and ending with none found
+	Rows []Row
+}
+
+// Row is one combination of expressions being found, and whether the condition is met
+type Row struct {
+	// Found has whether each of Table.Terms is found in the target
+	Found []bool
+	// Result is whether the condition is met
+	Result bool
+}
+
+// TruthTable enumerates whether pre-prepared tokens are met for every combination of their expressions being found,
+// so that complex conditions can be checked systematically. For instance, lazy | !dog has the table:
+//
+//	"lazy" "dog" | result
+//	true   true  | true
+//	true   false | true
+//	false  true  | false
+//	false  false | true
+//
+// The empty expression is contained in every target, so it is not a column of the table.
+// Expressions are treated as independent of each other, so the table includes combinations that cannot happen
+// when one expression contains another, such as fox found and fo not found.
+//
+// A *LimitError of kind TermsLimit is returned if there are more than 16 distinct expressions,
+// and an error is returned if the tokens are not a valid postfix condition.
+func TruthTable(preparation PreparedTokens) (*Table, error) {
+	tree, err := buildTree(preparation)
+	if err != nil {
+		return nil, err
+	}
+
+	e := toExpr(tree)
+	terms := []string{}
+	index := map[string]int{}
+	for _, term := range e.terms() {
+		if term != "" {
+			index[term] = len(terms)
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) > maxTruthTableTerms {
+		return nil, &LimitError{Kind: TermsLimit, Max: maxTruthTableTerms, Actual: len(terms)}
+	}
+
+	rows := make([]Row, 1<<len(terms))
+	for i := range rows {
+		// the first term is the most significant bit, and a set bit is not found, so rows count down from all found
+		found := make([]bool, len(terms))
+		for t := range terms {
+			found[t] = i&(1<<(len(terms)-1-t)) == 0
+		}
+		rows[i] = Row{Found: found, Result: e.eval(func(term string) bool {
+			return term == "" || found[index[term]]
+		})}
+	}
+
+	return &Table{Terms: terms, Rows: rows}, nil
+}
+
+// String renders the table as aligned plain text, with the expressions quoted. See TruthTable for an example.
+func (t *Table) String() string {
+	header := make([]string, len(t.Terms))
+	for i, term := range t.Terms {
+		header[i] = quoteTerm(term)
+	}
+
+	widths := make([]int, len(header))
+	for i, cell := range header {
+		widths[i] = utf8.RuneCountInString(cell)
+		if widths[i] < len("false") {
+			widths[i] = len("false")
+		}
+	}
+
+	var sb strings.Builder
+	writeLine := func(cells []string, result string) {
+		for i, cell := range cells {
+			sb.WriteString(cell)
+			sb.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+1))
+		}
+		sb.WriteString("| ")
+		sb.WriteString(result)
+		sb.WriteString("\n")
+	}
+
+	writeLine(header, "result")
+	for _, row := range t.Rows {
+		writeLine(row.cells(), strconv.FormatBool(row.Result))
+	}
+	return sb.String()
+}
+
+// Markdown renders the table as a markdown table, with the expressions as code
+func (t *Table) Markdown() string {
+	var sb strings.Builder
+	writeLine := func(cells []string) {
+		sb.WriteString("|")
+		for _, cell := range cells {
+			sb.WriteString(" ")
+			sb.WriteString(cell)
+			sb.WriteString(" |")
+		}
+		sb.WriteString("\n")
+	}
+
+	header := make([]string, 0, len(t.Terms)+1)
+	rule := make([]string, 0, len(t.Terms)+1)
+	for _, term := range t.Terms {
+		header = append(header, markdownCode(term))
+		rule = append(rule, "---")
+	}
+	writeLine(append(header, "result"))
+	writeLine(append(rule, "---"))
+
+	for _, row := range t.Rows {
+		writeLine(append(row.cells(), strconv.FormatBool(row.Result)))
+	}
+	return sb.String()
+}
+
+// CSV renders the table as comma separated values, with a header of the expressions as they are and "result"
+func (t *Table) CSV() string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	// writing to a bytes.Buffer cannot fail
+	_ = w.Write(append(append([]string{}, t.Terms...), "result"))
+	for _, row := range t.Rows {
+		_ = w.Write(append(row.cells(), strconv.FormatBool(row.Result)))
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// cells formats whether each expression is found
+func (r Row) cells() []string {
+	cells := make([]string, len(r.Found), len(r.Found)+1)
+	for i, found := range r.Found {
+		cells[i] = strconv.FormatBool(found)
+	}
+	return cells
+}
+
+// markdownCode renders an expression as inline code in a markdown table cell
+func markdownCode(term string) string {
+	fence := "`"
+	for strings.Contains(term, fence) {
+		fence += "`"
+	}
+	// pipes end the cell even inside code, and the padding keeps backticks at either end apart from the fence
+	return fence + " " + strings.ReplaceAll(term, "|", "\\|") + " " + fence
+}
@@ -0,0 +1,155 @@
+// Package types: the token types and syntax definitions of stoc.
+//
+// The types of the lexer (github.com/kranzuft/boolean-algebra-to-tokens) are re-exported, so a syntax can be defined
+// with this package alone, along with options that only stoc understands. An option is enabled by defining its token
+// type in a TokensDefinition, for instance:
+//
+//	def := types.Definition{}
+//	def.DefineTokenInfo(types.AND, "and", "and").
+//		DefineTokenInfo(types.CASE_INSENSITIVE, "", "case insensitive keywords")
+package types
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"strings"
+)
+
+// Token is a token of a condition, see the types of the lexer
+type Token = types.Token
+
+// TokenType is the type of a token, or an option of a TokensDefinition
+type TokenType = types.TokenType
+
+// TokenInfo is the keyword and description of a token type
+type TokenInfo = types.TokenInfo
+
+// TokensDefinition defines the syntax of conditions, see the types of the lexer
+type TokensDefinition = types.TokensDefinition
+
+// The token types of the lexer
+const (
+	EOL     = types.EOL
+	UNKNOWN = types.UNKNOWN
+	AND     = types.AND
+	OR      = types.OR
+	ANDNOT  = types.ANDNOT
+	ORNOT   = types.ORNOT
+	NOT     = types.NOT
+	EXP     = types.EXP
+	LBR     = types.LBR
+	RBR     = types.RBR
+	TRUE    = types.TRUE
+	DQUOTE  = types.DQUOTE
+	SQUOTE  = types.SQUOTE
+)
+
+// The options of stoc. Their keys are ignored unless stated otherwise.
+const (
+	// CASE_INSENSITIVE makes keywords match regardless of case, so AND, And and and are all the and keyword.
+	// Quoted expressions are never keywords, so 'AND' is still an expression.
+	CASE_INSENSITIVE TokenType = "CASE_INSENSITIVE"
+	// IMPLICIT_AND joins terms separated only by whitespace with and, so error timeout is error and timeout.
+	// Expressions containing whitespace, such as phrases, must then be quoted.
+	IMPLICIT_AND TokenType = "IMPLICIT_AND"
+	// ESCAPE enables escape sequences in expressions, quoted or not: \" and \' for quotes, \\ for a backslash,
+	// \n and \t for a line break and a tab, and \uXXXX for any unicode character by its hexadecimal code, other than
+	// the surrogates D800 to DFFF, which are not characters.
+	// A backslash before any other character is that character, so \& is & rather than the keyword.
+	ESCAPE TokenType = "ESCAPE"
+	// COMMENT enables comments and conditions over many lines, so conditions can be kept in files. A comment starts
+	// with the key of COMMENT, or # if it has none, at the start of a line or after whitespace, and runs to the end
+	// of the line. Line breaks separate tokens as spaces do, and in an expression that is not quoted, a line break and
+	// the whitespace around it are a single space.
+	COMMENT TokenType = "COMMENT"
+)
+
+// DefaultTokensDefinition is the default syntax, such as lazy & !(fox | dog)
+var DefaultTokensDefinition = types.DefaultTokensDefinition
+
+// IsWhitespace returns true if r has whitespace at index, as the lexer skips between tokens
+func IsWhitespace(r []rune, index int) bool {
+	return types.IsWhitespace(r, index)
+}
+
+// Definition defines a TokensDefinition as the DefineTokenInfo method of TokensDefinition does, also recording the key
+// of each token type, which the lexer does not make available. stoc needs the keys of a syntax to write conditions in
+// it, as stoc.Format does, and to check it, so syntaxes other than DefaultTokensDefinition should be defined with a
+// Definition:
+//
+//	def := types.Definition{}
+//	defs := def.DefineTokenInfo(types.AND, "and", "and").
+//		DefineTokenInfo(types.OR, "or", "or").
+//		Finalise()
+type Definition TokensDefinition
+
+// DefineTokenInfo defines typ with key and description, and records key. d is returned, to chain definitions.
+func (d *Definition) DefineTokenInfo(typ TokenType, key string, description string) *Definition {
+	defs := TokensDefinition(*d)
+	defs.DefineTokenInfo(typ, key, description).
+		DefineTokenInfo(keyRecord(typ), "", key)
+	return d
+}
+
+// Finalise returns the TokensDefinition d has defined
+func (d *Definition) Finalise() TokensDefinition {
+	return TokensDefinition(*d)
+}
+
+// keyRecordPrefix starts the token types under which a Definition records keys, as their descriptions
+const keyRecordPrefix = "KEY OF "
+
+// keyRecord is the token type under which a Definition records the key of typ
+func keyRecord(typ TokenType) TokenType {
+	return keyRecordPrefix + typ
+}
+
+// IsKeyRecord returns true if typ is not a token type, but where a Definition records the key of one
+func IsKeyRecord(typ TokenType) bool {
+	return strings.HasPrefix(string(typ), keyRecordPrefix)
+}
+
+// Key gets the key of typ in defs, and whether it is known. The key is known if typ was defined with a Definition,
+// or with the key and description it has in DefaultTokensDefinition.
+func Key(defs TokensDefinition, typ TokenType) (string, bool) {
+	if _, ok := defs[typ]; !ok {
+		return "", false
+	}
+
+	if _, ok := defs[keyRecord(typ)]; ok {
+		if key := defs.TokToString(keyRecord(typ)); hasKey(defs, typ, key) {
+			return key, true
+		}
+	}
+
+	if key, ok := defaultKeys[typ]; ok && defs.TokToString(typ) == DefaultTokensDefinition.TokToString(typ) && hasKey(defs, typ, key) {
+		return key, true
+	}
+	return "", false
+}
+
+// hasKey checks key against the key of typ in defs, for the token types the lexer can match, so that a key defined
+// again since it was recorded is not mistaken for the recorded one
+func hasKey(defs TokensDefinition, typ TokenType, key string) bool {
+	runes := []rune(key)
+	switch typ {
+	case AND:
+		return defs.IsAnd(runes, 0) && defs.IsAndI() == len(runes)
+	case OR:
+		return defs.IsOr(runes, 0) && defs.IsOrI() == len(runes)
+	case NOT:
+		return defs.IsNot(runes, 0) && defs.IsNotI() == len(runes)
+	case LBR:
+		return defs.IsLeftBracket(runes, 0) && defs.IsLeftBracketI() == len(runes)
+	case RBR:
+		return defs.IsRightBracket(runes, 0) && defs.IsRightBracketI() == len(runes)
+	case DQUOTE:
+		return defs.IsDoubleInvertedComma(runes, 0)
+	case SQUOTE:
+		return defs.IsSingleInvertedComma(runes, 0)
+	}
+	return true
+}
+
+// defaultKeys are the keys of DefaultTokensDefinition, which is defined by the lexer rather than with a Definition
+var defaultKeys = map[TokenType]string{AND: "&", OR: "|", NOT: "!", ANDNOT: "&!", ORNOT: "&!", TRUE: "True", LBR: "(",
+	RBR: ")", EOL: "\n", EXP: "", DQUOTE: "\"", SQUOTE: "'"}
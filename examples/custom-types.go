@@ -7,7 +7,7 @@ import (
 )
 
 func main() {
-	def := types.TokensDefinition{}
+	def := types.Definition{}
 	customTypes := def.DefineTokenInfo(types.AND, "and", "and").
 		DefineTokenInfo(types.OR, "or", "or").
 		DefineTokenInfo(types.NOT, "not", "not").
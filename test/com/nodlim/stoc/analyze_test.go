@@ -0,0 +1,101 @@
+package com_nodlim_stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// Analyze describes each finding, we just want to know what was found and where
+func Analyze(condition string) []string {
+	findings, _ := stoc.Analyze(types.DefaultTokensDefinition, condition)
+	var described []string
+	for _, finding := range findings {
+		described = append(described, finding.String())
+	}
+	return described
+}
+
+/**
+ * BDD Tests
+ */
+var _ = Describe("Analyze conditions", func() {
+	Describe("contradictions", func() {
+		It("should be always false", func() {
+			Expect(Analyze("foo & !foo")).To(Equal([]string{"0-10: always false: foo & !foo"}))
+			Expect(Analyze("bar | (foo &! 'foo')")).To(Equal([]string{"6-20: always false: (foo &! 'foo')"}))
+			Expect(Analyze("!(foo | !foo)")).To(Equal([]string{"0-13: always false: !(foo | !foo)"}))
+		})
+	})
+
+	Describe("tautologies", func() {
+		It("should be always true", func() {
+			Expect(Analyze("foo | !foo")).To(Equal([]string{"0-10: always true: foo | !foo"}))
+			Expect(Analyze("bar & (foo | !foo)")).To(Equal([]string{"6-18: always true: (foo | !foo)"}))
+		})
+
+		It("should find the empty expression always true", func() {
+			Expect(Analyze("bar & (foo | '')")).To(Equal([]string{"6-16: always true: (foo | '')"}))
+		})
+
+		It("should only report the outermost", func() {
+			Expect(Analyze("(foo | !foo) | (bar & !bar)")).To(Equal([]string{"0-27: always true: (foo | !foo) | (bar & !bar)"}))
+		})
+	})
+
+	Describe("redundancy", func() {
+		It("should find repeated operands", func() {
+			Expect(Analyze("foo & foo")).To(Equal([]string{"6-9: redundant: foo"}))
+			Expect(Analyze("foo & bar & \"foo\"")).To(Equal([]string{"12-17: redundant: \"foo\""}))
+		})
+
+		It("should include the not keyword of complex operators", func() {
+			Expect(Analyze("!foo & !(foo & bar)")).To(Equal([]string{"7-19: redundant: !(foo & bar)"}))
+			Expect(Analyze("!foo & ! 'foo'")).To(Equal([]string{"7-14: redundant: ! 'foo'"}))
+		})
+
+		It("should find absorbed operands", func() {
+			Expect(Analyze("foo | (foo & bar)")).To(Equal([]string{"6-17: redundant: (foo & bar)"}))
+			Expect(Analyze("(foo | bar) & foo")).To(Equal([]string{"0-11: redundant: (foo | bar)"}))
+		})
+
+		It("should find problems in each operand", func() {
+			Expect(Analyze("(a & a) | ((b | !b) & c)")).To(Equal([]string{"5-6: redundant: a", "11-19: always true: (b | !b)"}))
+		})
+	})
+
+	Describe("the complex example", func() {
+		It("should find the redundant parts", func() {
+			Expect(Analyze(conditionCorpus[0])).To(Equal([]string{
+				"21-39: redundant: ((((lazy & dog))))",
+				"42-61: redundant: !((((lazy | dog))))",
+				"64-83: redundant: ((((!lazy & dog))))",
+			}))
+		})
+	})
+
+	Describe("conditions without problems", func() {
+		It("should have no findings", func() {
+			Expect(Analyze("foo & !bar | (baz & qux)")).To(BeEmpty())
+			Expect(Analyze("'foo' & \"foo bar\"")).To(BeEmpty())
+		})
+	})
+
+	Describe("other syntaxes", func() {
+		It("should use positions in the condition", func() {
+			findings, err := stoc.Analyze(wordsTokensDefinition, "x or {foo and not foo}")
+			Expect(err).To(BeNil())
+			Expect(findings).To(Equal([]stoc.Finding{{Kind: stoc.AlwaysFalse, Start: 5, End: 22, Text: "{foo and not foo}"}}))
+		})
+	})
+
+	Describe("invalid condition", func() {
+		It("should return the lexing error", func() {
+			_, err := stoc.Analyze(types.DefaultTokensDefinition, "(foo & bar")
+			Expect(err).NotTo(BeNil())
+			_, err = stoc.Analyze(types.DefaultTokensDefinition, "foo &")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
@@ -0,0 +1,104 @@
+package com_nodlim_stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+// Get rid of error, we just want to no if success or not
+func SearchBytes(command string, target []byte) bool {
+	success, _ := stoc.SearchBytes(command, target)
+	return success
+}
+
+/**
+ * BDD Tests
+ */
+var _ = Describe("Search byte slices", func() {
+	Describe("the condition corpus", func() {
+		It("should agree with SearchString", func() {
+			for _, condition := range conditionCorpus {
+				for _, target := range targetCorpus {
+					Expect(SearchBytes(condition, []byte(target))).To(Equal(SearchString(condition, target)), condition)
+				}
+			}
+		})
+	})
+
+	Describe("nil target", func() {
+		It("should be treated as empty", func() {
+			Expect(SearchBytes("foo", nil)).To(Equal(false))
+			Expect(SearchBytes("!foo", nil)).To(Equal(true))
+		})
+	})
+
+	Describe("invalid condition", func() {
+		It("should return the lexing error", func() {
+			success, err := stoc.SearchBytes("foo &", []byte(shortTargetProse))
+			Expect(success).To(Equal(false))
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Describe("allocations", func() {
+		It("should not allocate when searching pre-prepared tokens", func() {
+			target := []byte(longTargetProse)
+			for _, condition := range conditionCorpus {
+				prepared, _ := stoc.LexIntoTokens(types.DefaultTokensDefinition, condition)
+				allocs := testing.AllocsPerRun(10, func() {
+					stoc.SearchTokensBytes(prepared, target)
+				})
+				Expect(allocs).To(Equal(0.0), condition)
+			}
+		})
+	})
+})
+
+/**
+ * Benchmarks
+ */
+
+func benchmarkPostfixBytes(b *testing.B, conditions []string, targets []string) {
+	var prepared []stoc.PreparedTokens
+	for _, condition := range conditions {
+		tokens, _ := stoc.LexIntoTokens(types.DefaultTokensDefinition, condition)
+		prepared = append(prepared, tokens)
+	}
+	var buffers [][]byte
+	for _, target := range targets {
+		buffers = append(buffers, []byte(target))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, tokens := range prepared {
+			for _, buffer := range buffers {
+				stoc.SearchPostfixTokensBytes(tokens, buffer)
+			}
+		}
+	}
+}
+
+func BenchmarkPostfixBytesCorpus(b *testing.B) {
+	benchmarkPostfixBytes(b, conditionCorpus, targetCorpus)
+}
+
+func BenchmarkPostfixBytesLargeTarget(b *testing.B) {
+	benchmarkPostfixBytes(b, []string{selectiveCondition}, []string{largeTarget})
+}
+
+// BenchmarkPostfixConvertedLargeTarget is the cost of converting to a string to call SearchPostfixTokens
+func BenchmarkPostfixConvertedLargeTarget(b *testing.B) {
+	tokens, _ := stoc.LexIntoTokens(types.DefaultTokensDefinition, selectiveCondition)
+	buffer := []byte(largeTarget)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stoc.SearchPostfixTokens(tokens, string(buffer))
+	}
+}
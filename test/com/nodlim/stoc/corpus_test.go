@@ -0,0 +1,50 @@
+package com_nodlim_stoc
+
+import "strings"
+
+// Test data shared by specs that check a feature agrees with SearchString, taken from the search specs
+var conditionCorpus = []string{
+	"!(((lazy & !dog))) | ((((lazy & dog)))) | !((((lazy | dog)))) | ((((!lazy & dog))))",
+	"The",
+	"!Thee",
+	"are | brevity",
+	"laser | fox",
+	"lazy & foxy",
+	"Programmered & mistake",
+	"lazy|(the|jumped)",
+	"Programmers|(mistake|zariable)",
+	"ladπle|(the|jumped)",
+	"Proƒgrammering|(mßistakes|variabl∂es)",
+	"lazy|!(the|!zumped)",
+	"Programmers|!(mis˚take|!variable)",
+	"Programmers &! mistake",
+	"Frog &      ! mistook",
+	"lazy |  ! fox",
+	"!Programmers &! mistake",
+	"!lazy |  ! fox",
+	"(!lazyish &! fox)",
+	"(!Frog &       mistook)",
+	"(!are | bravado)",
+	"\"Programmers\"",
+	"!\"The\"",
+	"\"lazy\" | \"abc\"",
+	"\"Programmers\"|(\"mistake\"|\"variable\")",
+	"\"lazy\"|!(\"the\"|!\"jumped\")",
+	"\"Prop\" &! \"mistake\"",
+	"\"arsenal\" |! \"brevado\"",
+	"'lazy' & ('fox' | 'dog') & !('cat' | 'fence')",
+	"(lazy & (fox | (jumped & !(over | under)))) | !(fence & the)",
+	"lazy & fox & jumped & over & fence & The",
+	"dog | cat | mouse | horse | fence",
+	"'ƒ˚¬' | 'is a mistake' & !'that is'",
+}
+
+// Test data the corpus is searched against
+var targetCorpus = []string{
+	shortTargetProse,
+	longTargetProse,
+	"",
+	"the lazy dog",
+	"a cat and a dog jumped the fence",
+	strings.Repeat(longTargetProse, 100),
+}
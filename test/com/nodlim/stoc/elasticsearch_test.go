@@ -0,0 +1,80 @@
+package com_nodlim_stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
+)
+
+// ToElasticsearch renders the command on the body field, we just want the JSON
+func ToElasticsearch(command string) string {
+	query, _ := stoc.ToElasticsearch(LexIntoTokens(command), "body")
+	return string(query)
+}
+
+// golden reads a fixture of the expected JSON
+func golden(name string) string {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "elasticsearch", name+".json"))
+	Expect(err).To(BeNil())
+	return string(fixture)
+}
+
+/**
+ * Elasticsearch Tests
+ */
+var _ = Describe("Elasticsearch queries of conditions", func() {
+	It("should match the golden fixtures", func() {
+		fixtures := []struct {
+			command string
+			fixture string
+		}{
+			{"lazy", "expression"},
+			{"'the lazy dog'", "phrase"},
+			{"lazy & fox & dog", "and"},
+			{"lazy | fox | dog", "or"},
+			{"!lazy", "not"},
+			{"lazy &! fox & !dog", "andnot"},
+			{"lazy |! fox", "ornot"},
+			{"lazy & !(fox | \"big dog\") | cat", "nested"},
+			{"!('' & lazy)", "empty"},
+		}
+		for _, f := range fixtures {
+			Expect(ToElasticsearch(f.command)).To(MatchJSON(golden(f.fixture)), f.command)
+		}
+	})
+
+	It("should render the example of the documentation", func() {
+		Expect(ToElasticsearch("lazy & !(fox | dog)")).To(MatchJSON(`{"bool": {
+			"must": [{"match_phrase": {"body": "lazy"}}],
+			"must_not": [{"bool": {
+				"should": [{"match_phrase": {"body": "fox"}}, {"match_phrase": {"body": "dog"}}],
+				"minimum_should_match": 1
+			}}]
+		}}`))
+	})
+
+	It("should render constants", func() {
+		always := stoc.PreparedTokens{{Typ: types.TRUE, Exp: "true"}}
+		query, err := stoc.ToElasticsearch(always, "body")
+		Expect(err).To(BeNil())
+		Expect(query).To(MatchJSON(`{"match_all": {}}`))
+
+		never := stoc.PreparedTokens{{Typ: types.TRUE, Exp: "true"}, {Typ: types.TRUE, Exp: "true"}, {Typ: types.ANDNOT, Exp: "!"}}
+		query, err = stoc.ToElasticsearch(never, "body")
+		Expect(err).To(BeNil())
+		Expect(query).To(MatchJSON(`{"match_none": {}}`))
+	})
+
+	It("should require a field", func() {
+		_, err := stoc.ToElasticsearch(LexIntoTokens("lazy"), "")
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should reject invalid tokens", func() {
+		_, err := stoc.ToElasticsearch(stoc.PreparedTokens{{Typ: types.AND, Exp: "&"}}, "body")
+		Expect(err).NotTo(BeNil())
+	})
+})
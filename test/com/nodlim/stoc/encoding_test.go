@@ -0,0 +1,120 @@
+package com_nodlim_stoc
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// Get rid of error, we just want the tokens
+func LexIntoTokens(command string) stoc.PreparedTokens {
+	prepared, _ := stoc.LexIntoTokens(types.DefaultTokensDefinition, command)
+	return prepared
+}
+
+/**
+ * BDD Tests
+ */
+var _ = Describe("Encode prepared tokens", func() {
+	Describe("JSON", func() {
+		It("should round trip the condition corpus", func() {
+			for _, condition := range conditionCorpus {
+				prepared := LexIntoTokens(condition)
+				data, err := json.Marshal(prepared)
+				Expect(err).To(BeNil())
+
+				var decoded stoc.PreparedTokens
+				Expect(json.Unmarshal(data, &decoded)).To(Succeed())
+				Expect(decoded).To(Equal(prepared), condition)
+			}
+		})
+
+		It("should have a stable format", func() {
+			data, err := json.Marshal(LexIntoTokens("foo & !bar"))
+			Expect(err).To(BeNil())
+			Expect(string(data)).To(Equal(`{"version":1,"tokens":[` +
+				`{"type":"EXPRESSION","exp":"foo"},{"type":"EXPRESSION","exp":"bar"},{"type":"ANDNOT","exp":"\u0026 !"}]}`))
+		})
+
+		It("should reject invalid payloads", func() {
+			payloads := []string{
+				`{"version":2,"tokens":[{"type":"EXPRESSION","exp":"foo"}]}`,
+				`{"version":1,"tokens":[]}`,
+				`{"version":1,"tokens":[{"type":"AND","exp":"&"}]}`,
+				`{"version":1,"tokens":[{"type":"EXPRESSION","exp":"foo"},{"type":"EXPRESSION","exp":"bar"}]}`,
+				`{"version":1,"tokens":[{"type":"LEFT_BRACKET","exp":"("}]}`,
+				`{"version":1,"tokens":[{"type":"MADE_UP","exp":"foo"}]}`,
+			}
+			for _, payload := range payloads {
+				var decoded stoc.PreparedTokens
+				err := json.Unmarshal([]byte(payload), &decoded)
+				Expect(errors.Is(err, stoc.ErrInvalidEncoding)).To(Equal(true), payload)
+				Expect(decoded).To(BeNil())
+			}
+
+			var decoded stoc.PreparedTokens
+			Expect(json.Unmarshal([]byte(`{"version":1,"tokens":`), &decoded)).NotTo(Succeed())
+		})
+	})
+
+	Describe("binary", func() {
+		It("should round trip the condition corpus", func() {
+			for _, condition := range conditionCorpus {
+				prepared := LexIntoTokens(condition)
+				data, err := prepared.MarshalBinary()
+				Expect(err).To(BeNil())
+
+				var decoded stoc.PreparedTokens
+				Expect(decoded.UnmarshalBinary(data)).To(Succeed())
+				Expect(decoded).To(Equal(prepared), condition)
+			}
+		})
+
+		It("should be compact", func() {
+			data, err := LexIntoTokens("foo & !bar").MarshalBinary()
+			Expect(err).To(BeNil())
+			Expect(data).To(Equal([]byte("STOC\x01\x03\x01\x03foo\x01\x03bar\x05\x03& !")))
+		})
+
+		It("should not marshal unknown token types", func() {
+			_, err := stoc.PreparedTokens{{Typ: types.LBR, Exp: "("}}.MarshalBinary()
+			Expect(errors.Is(err, stoc.ErrInvalidEncoding)).To(Equal(true))
+		})
+
+		It("should reject truncated and corrupted payloads without panicking", func() {
+			condition := conditionCorpus[0]
+			data, _ := LexIntoTokens(condition).MarshalBinary()
+
+			for size := 0; size < len(data); size++ {
+				var decoded stoc.PreparedTokens
+				Expect(errors.Is(decoded.UnmarshalBinary(data[:size]), stoc.ErrInvalidEncoding)).To(Equal(true))
+			}
+
+			for i := range data {
+				for _, flip := range []byte{0x01, 0x80, 0xff} {
+					corrupted := append([]byte{}, data...)
+					corrupted[i] ^= flip
+
+					var decoded stoc.PreparedTokens
+					if err := decoded.UnmarshalBinary(corrupted); err == nil {
+						// corruption of the text of a token may still be valid
+						Expect(func() { stoc.SearchTokens(decoded, shortTargetProse) }).NotTo(Panic())
+					} else {
+						Expect(errors.Is(err, stoc.ErrInvalidEncoding)).To(Equal(true))
+					}
+				}
+			}
+		})
+	})
+
+	Describe("validating tokens", func() {
+		It("should accept lexed tokens", func() {
+			for _, condition := range conditionCorpus {
+				Expect(stoc.ValidateTokens(LexIntoTokens(condition))).To(Succeed())
+			}
+		})
+	})
+})
@@ -0,0 +1,104 @@
+package com_nodlim_stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"math/rand"
+)
+
+// Equivalent compares the commands, we just want the answer
+func Equivalent(a string, b string) bool {
+	equivalent, _ := stoc.Equivalent(LexIntoTokens(a), LexIntoTokens(b))
+	return equivalent
+}
+
+// Subsumes compares the commands, we just want the answer
+func Subsumes(general string, specific string) bool {
+	subsumes, _ := stoc.Subsumes(LexIntoTokens(general), LexIntoTokens(specific))
+	return subsumes
+}
+
+/**
+ * Equivalence and Subsumption Tests
+ */
+var _ = Describe("Equivalence of conditions", func() {
+	It("should find conditions written differently equivalent", func() {
+		Expect(Equivalent("foo", "'foo'")).To(BeTrue())
+		Expect(Equivalent("a & b", "b & a")).To(BeTrue())
+		Expect(Equivalent("a & (b | c)", "(a & b) | (a & c)")).To(BeTrue())
+		Expect(Equivalent("!(a | b)", "!a & !b")).To(BeTrue())
+		Expect(Equivalent("a | (a & b)", "a")).To(BeTrue())
+		Expect(Equivalent("a & !a", "b & !b")).To(BeTrue())
+		Expect(Equivalent("a | !a", "!(b & !b)")).To(BeTrue())
+	})
+
+	It("should not find different conditions equivalent", func() {
+		Expect(Equivalent("a", "b")).To(BeFalse())
+		Expect(Equivalent("a & b", "a | b")).To(BeFalse())
+		Expect(Equivalent("a & !b", "!a & b")).To(BeFalse())
+		Expect(Equivalent("a | !a", "a & !a")).To(BeFalse())
+	})
+
+	It("should treat overlapping expressions as independent", func() {
+		// every target containing foxes contains fox, but that is not known to Equivalent
+		Expect(Equivalent("fox | foxes", "fox")).To(BeFalse())
+	})
+
+	It("should treat the empty expression as true, as every target contains it", func() {
+		Expect(Equivalent("foo & ''", "foo")).To(BeTrue())
+		Expect(Equivalent("foo | ''", "bar | !bar")).To(BeTrue())
+		Expect(Equivalent("!''", "bar & !bar")).To(BeTrue())
+		Expect(Subsumes("''", "foo")).To(BeTrue())
+	})
+
+	It("should agree with simplified and minimised conditions", func() {
+		random := rand.New(rand.NewSource(37))
+		for i := 0; i < 200; i++ {
+			tokens := randomTokens(random, 4)
+			simplified, err := stoc.Simplify(tokens)
+			Expect(err).To(BeNil())
+			minimised, err := stoc.Minimise(tokens)
+			Expect(err).To(BeNil())
+
+			Expect(stoc.Equivalent(tokens, simplified)).To(BeTrue())
+			Expect(stoc.Equivalent(tokens, minimised)).To(BeTrue())
+		}
+	})
+
+	It("should reject invalid tokens", func() {
+		invalid := stoc.PreparedTokens{{Typ: types.AND, Exp: "&"}}
+		_, err := stoc.Equivalent(invalid, LexIntoTokens("a"))
+		Expect(err).NotTo(BeNil())
+		_, err = stoc.Subsumes(LexIntoTokens("a"), invalid)
+		Expect(err).NotTo(BeNil())
+	})
+})
+
+var _ = Describe("Subsumption of conditions", func() {
+	It("should find more general conditions", func() {
+		Expect(Subsumes("foo | bar", "foo & baz")).To(BeTrue())
+		Expect(Subsumes("a", "a & b")).To(BeTrue())
+		Expect(Subsumes("a | b", "a")).To(BeTrue())
+		Expect(Subsumes("!c", "a & !(b | c)")).To(BeTrue())
+		Expect(Subsumes("a | !a", "b")).To(BeTrue())
+		Expect(Subsumes("b", "a & !a")).To(BeTrue())
+	})
+
+	It("should subsume equivalent conditions both ways", func() {
+		Expect(Subsumes("a & b", "b & a")).To(BeTrue())
+		Expect(Subsumes("b & a", "a & b")).To(BeTrue())
+	})
+
+	It("should not find more specific or unrelated conditions", func() {
+		Expect(Subsumes("a & b", "a")).To(BeFalse())
+		Expect(Subsumes("a", "a | b")).To(BeFalse())
+		Expect(Subsumes("a", "b")).To(BeFalse())
+		Expect(Subsumes("a & !a", "b")).To(BeFalse())
+	})
+
+	It("should treat overlapping expressions as independent", func() {
+		Expect(Subsumes("fox", "foxes")).To(BeFalse())
+	})
+})
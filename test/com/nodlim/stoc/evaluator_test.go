@@ -0,0 +1,101 @@
+package com_nodlim_stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+	"testing"
+)
+
+// Get rid of error, we just want the evaluator
+func NewEvaluator(command string) *stoc.Evaluator {
+	prepared, _ := stoc.LexIntoTokens(types.DefaultTokensDefinition, command)
+	evaluator, _ := stoc.NewEvaluator(prepared)
+	return evaluator
+}
+
+/**
+ * BDD Tests
+ */
+var _ = Describe("Evaluate with short-circuits", func() {
+	Describe("the condition corpus", func() {
+		It("should agree with SearchString", func() {
+			for _, condition := range conditionCorpus {
+				evaluator := NewEvaluator(condition)
+				for _, target := range targetCorpus {
+					Expect(evaluator.Search(target)).To(Equal(SearchString(condition, target)), condition)
+				}
+			}
+		})
+	})
+
+	Describe("invalid tokens", func() {
+		It("should return an error", func() {
+			_, err := stoc.NewEvaluator(stoc.PreparedTokens{{Typ: types.EXP, Exp: "a"}, {Typ: types.OR, Exp: "|"}})
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
+
+/**
+ * Benchmarks
+ */
+
+// largeTarget is a target of around a megabyte
+var largeTarget = strings.Repeat(longTargetProse+"\n", 8000)
+
+// selectiveCondition is decided by its last term, so benefits from reordering
+const selectiveCondition = "(fox | lazy | variable | mistake) & (brevity | clarity) & 'unfindable words'"
+
+func benchmarkPostfix(b *testing.B, conditions []string, targets []string) {
+	var prepared []stoc.PreparedTokens
+	for _, condition := range conditions {
+		tokens, _ := stoc.LexIntoTokens(types.DefaultTokensDefinition, condition)
+		prepared = append(prepared, tokens)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, tokens := range prepared {
+			for _, target := range targets {
+				stoc.SearchPostfixTokens(tokens, target)
+			}
+		}
+	}
+}
+
+func benchmarkEvaluator(b *testing.B, conditions []string, targets []string) {
+	var evaluators []*stoc.Evaluator
+	for _, condition := range conditions {
+		evaluators = append(evaluators, NewEvaluator(condition))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, evaluator := range evaluators {
+			for _, target := range targets {
+				evaluator.Search(target)
+			}
+		}
+	}
+}
+
+func BenchmarkPostfixCorpus(b *testing.B) {
+	benchmarkPostfix(b, conditionCorpus, targetCorpus)
+}
+
+func BenchmarkEvaluatorCorpus(b *testing.B) {
+	benchmarkEvaluator(b, conditionCorpus, targetCorpus)
+}
+
+func BenchmarkPostfixLargeTarget(b *testing.B) {
+	benchmarkPostfix(b, []string{selectiveCondition}, []string{largeTarget})
+}
+
+func BenchmarkEvaluatorLargeTarget(b *testing.B) {
+	benchmarkEvaluator(b, []string{selectiveCondition}, []string{largeTarget})
+}
@@ -0,0 +1,86 @@
+package com_nodlim_stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// Get rid of error, we just want the explanation
+func ExplainString(command string, target string) *stoc.Explanation {
+	explanation, _ := stoc.ExplainString(command, target)
+	return explanation
+}
+
+/**
+ * BDD Tests
+ */
+var _ = Describe("Explain a search", func() {
+	Describe("singular expression (A)", func() {
+		It("should record the result and hits", func() {
+			explanation := ExplainString("the", shortTargetProse)
+			Expect(explanation.Result).To(Equal(true))
+			Expect(explanation.Hits).To(Equal([]int{25}))
+			Expect(explanation.String()).To(Equal(`"the"[true @25]`))
+
+			explanation = ExplainString("dog", shortTargetProse)
+			Expect(explanation.Result).To(Equal(false))
+			Expect(explanation.Hits).To(BeNil())
+			Expect(explanation.String()).To(Equal(`"dog"[false]`))
+		})
+
+		It("should record every hit", func() {
+			Expect(ExplainString("is", longTargetProse).Hits).To(Equal([]int{88, 94, 110}))
+		})
+	})
+
+	Describe("expression with and-not operator (A.B̅)", func() {
+		It("should record the result of each operand", func() {
+			explanation := ExplainString("lazy & !fox", shortTargetProse)
+			Expect(explanation.Result).To(Equal(false))
+			Expect(explanation.Token.Typ).To(Equal(types.ANDNOT))
+			Expect(explanation.Left.Result).To(Equal(true))
+			Expect(explanation.Right.Result).To(Equal(true))
+			Expect(explanation.String()).To(Equal(`("lazy"[true @4] & ! "fox"[true @9])[false]`))
+		})
+	})
+
+	Describe("singular expression (A̅)", func() {
+		It("should render as a not", func() {
+			Expect(ExplainString("!dog", shortTargetProse).String()).To(Equal(`!"dog"[false][true]`))
+		})
+	})
+
+	Describe("complex example", func() {
+		It("should agree with the search at every level", func() {
+			condition := "!(((lazy & !dog))) | ((((lazy & dog)))) | !((((lazy | dog)))) | ((((!lazy & dog))))"
+			explanation := ExplainString(condition, shortTargetProse)
+			Expect(explanation.Result).To(Equal(SearchString(condition, shortTargetProse)))
+			Expect(explanation.String()).To(Equal(`(((!("lazy"[true @4] & ! "dog"[false])[true][false] | ` +
+				`("lazy"[true @4] & "dog"[false])[false])[false] | ` +
+				`! ("lazy"[true @4] | "dog"[false])[true])[false] | ` +
+				`(!"lazy"[true @4][false] & "dog"[false])[false])[false]`))
+		})
+	})
+
+	Describe("quoted expressions", func() {
+		It("should keep the quotes readable", func() {
+			Expect(ExplainString(`'say "hi"' | "it's"`, `it's`).String()).To(Equal(`('say "hi"'[false] | "it's"[true @0])[true]`))
+		})
+	})
+
+	Describe("invalid tokens", func() {
+		It("should return an error", func() {
+			_, err := stoc.Explain(stoc.PreparedTokens{{Typ: types.AND, Exp: "&"}}, shortTargetProse)
+			Expect(err).NotTo(BeNil())
+			_, err = stoc.Explain(nil, shortTargetProse)
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("should return the lexing error", func() {
+			_, err := stoc.ExplainString("foo &", shortTargetProse)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
@@ -0,0 +1,203 @@
+package com_nodlim_stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	stoctypes "github.com/kranzuft/stoc/cmd/com/nodlim/stoc/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"math/rand"
+	"strings"
+)
+
+// Test data
+func prepareWordsTokensDefinition() types.TokensDefinition {
+	def := stoctypes.Definition{}
+	return def.DefineTokenInfo(types.AND, "and", "and").
+		DefineTokenInfo(types.OR, "or", "or").
+		DefineTokenInfo(types.NOT, "not", "not").
+		DefineTokenInfo(types.ANDNOT, "and not", "and not").
+		DefineTokenInfo(types.ORNOT, "or not", "or not").
+		DefineTokenInfo(types.TRUE, "True", "true").
+		DefineTokenInfo(types.LBR, "{", "left bracket").
+		DefineTokenInfo(types.RBR, "}", "right bracket").
+		DefineTokenInfo(types.EOL, "\n", "end of line").
+		DefineTokenInfo(types.EXP, "", "expression").
+		DefineTokenInfo(types.DQUOTE, "\"", "double inverted comma").
+		DefineTokenInfo(types.SQUOTE, "'", "single inverted comma").
+		Finalise()
+}
+
+var wordsTokensDefinition = prepareWordsTokensDefinition()
+
+// termPool are expressions for random conditions, including ones that collide with keywords of either syntax
+var termPool = []string{"lazy", "fox", "the fence", " padded ", "&", "a|b", "(x)", "!", "it's", "say \"hi\"",
+	"", "android", "knot", "{curly}", "True", "ƒ˚¬"}
+
+// randomTokens generates random postfix tokens with up to depth levels of operators
+func randomTokens(rnd *rand.Rand, depth int) stoc.PreparedTokens {
+	return randomTokensFrom(rnd, depth, termPool)
+}
+
+// randomTokensFrom generates random postfix tokens with up to depth levels of operators, and expressions from pool
+func randomTokensFrom(rnd *rand.Rand, depth int, pool []string) stoc.PreparedTokens {
+	if depth == 0 || rnd.Intn(4) == 0 {
+		if rnd.Intn(10) == 0 {
+			return stoc.PreparedTokens{{Typ: types.TRUE, Exp: "true"}}
+		}
+		return stoc.PreparedTokens{{Typ: types.EXP, Exp: pool[rnd.Intn(len(pool))]}}
+	}
+
+	ops := []types.TokenType{types.AND, types.OR, types.ANDNOT, types.ORNOT}
+	op := ops[rnd.Intn(len(ops))]
+	var left stoc.PreparedTokens
+	if op == types.ANDNOT && rnd.Intn(3) == 0 {
+		left = stoc.PreparedTokens{{Typ: types.TRUE, Exp: "true"}}
+	} else {
+		left = randomTokensFrom(rnd, depth-1, pool)
+	}
+	right := randomTokensFrom(rnd, depth-1, pool)
+	return append(append(left, right...), types.Token{Typ: op})
+}
+
+// randomTargets generates targets from random selections of the term pool
+func randomTargets(rnd *rand.Rand, count int) []string {
+	targets := []string{""}
+	for len(targets) < count {
+		var sb strings.Builder
+		for _, term := range termPool {
+			if rnd.Intn(2) == 0 {
+				sb.WriteString(term + "~")
+			}
+		}
+		targets = append(targets, sb.String())
+	}
+	return targets
+}
+
+// Get rid of error, we just want the condition
+func Format(defs types.TokensDefinition, command string) string {
+	condition, _ := stoc.Format(defs, LexIntoTokens(command))
+	return condition
+}
+
+/**
+ * BDD Tests
+ */
+var _ = Describe("Format prepared tokens", func() {
+	Describe("minimal brackets", func() {
+		It("should only bracket right operands", func() {
+			Expect(Format(types.DefaultTokensDefinition, "((a & b)) | (c)")).To(Equal("a & b | c"))
+			Expect(Format(types.DefaultTokensDefinition, "a & (b | c)")).To(Equal("a & (b | c)"))
+			Expect(Format(types.DefaultTokensDefinition, "(a & (b | (c)))")).To(Equal("a & (b | c)"))
+		})
+
+		It("should only bracket the operand of a not when it is not an expression", func() {
+			Expect(Format(types.DefaultTokensDefinition, "!(a)")).To(Equal("!a"))
+			Expect(Format(types.DefaultTokensDefinition, "!(a | b) & c")).To(Equal("!(a | b) & c"))
+			Expect(Format(types.DefaultTokensDefinition, "a |! (b & c)")).To(Equal("a | !(b & c)"))
+			Expect(Format(types.DefaultTokensDefinition, "a & (!b)")).To(Equal("a & !b"))
+			Expect(Format(types.DefaultTokensDefinition, "a &! (!b)")).To(Equal("a & !(!b)"))
+		})
+
+		It("should normalise the complex example", func() {
+			Expect(Format(types.DefaultTokensDefinition, conditionCorpus[0])).
+				To(Equal("!(lazy & !dog) | (lazy & dog) | !(lazy | dog) | (!lazy & dog)"))
+		})
+	})
+
+	Describe("quotes", func() {
+		It("should only quote expressions that need quotes", func() {
+			Expect(Format(types.DefaultTokensDefinition, "'lazy' & \"the fox\"")).To(Equal("lazy & the fox"))
+			Expect(Format(types.DefaultTokensDefinition, "'a & b' | ' padded '")).To(Equal("\"a & b\" | \" padded \""))
+			Expect(Format(types.DefaultTokensDefinition, "'say \"hi\"' | \"it's\"")).To(Equal("say \"hi\" | it's"))
+			Expect(Format(types.DefaultTokensDefinition, "\"'quoted'\"")).To(Equal("\"'quoted'\""))
+			Expect(Format(types.DefaultTokensDefinition, "''")).To(Equal("\"\""))
+		})
+
+		It("should quote expressions containing keywords of the target syntax", func() {
+			Expect(Format(wordsTokensDefinition, "android | knot & 'a&b'")).To(Equal("\"android\" or \"knot\" and a&b"))
+		})
+
+		It("should return an error when every quote is in the expression", func() {
+			_, err := stoc.Format(types.DefaultTokensDefinition, stoc.PreparedTokens{{Typ: types.EXP, Exp: "'\" & x"}})
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Describe("word syntax", func() {
+		It("should separate words with spaces", func() {
+			Expect(Format(wordsTokensDefinition, "!a & !(b | c)")).To(Equal("not a and not {b or c}"))
+			Expect(Format(wordsTokensDefinition, "!(!a)")).To(Equal("not {not a}"))
+		})
+	})
+
+	Describe("types.TRUE", func() {
+		It("should be written as the empty expression when not negated", func() {
+			condition, err := stoc.Format(types.DefaultTokensDefinition, stoc.PreparedTokens{
+				{Typ: types.TRUE, Exp: "true"}, {Typ: types.EXP, Exp: "a"}, {Typ: types.ORNOT}})
+			Expect(err).To(BeNil())
+			Expect(condition).To(Equal("\"\" | !a"))
+		})
+	})
+
+	Describe("invalid input", func() {
+		It("should return an error", func() {
+			_, err := stoc.Format(types.DefaultTokensDefinition, stoc.PreparedTokens{{Typ: types.AND, Exp: "&"}})
+			Expect(err).NotTo(BeNil())
+			_, err = stoc.Format(types.TokensDefinition{}, LexIntoTokens("a"))
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("should return an error for a syntax whose keywords are not known", func() {
+			// the keys of a syntax defined with the lexer alone are not recorded, though it can still search
+			defs := types.TokensDefinition{}
+			defs.DefineTokenInfo(types.AND, "and", "and").
+				DefineTokenInfo(types.OR, "or", "or").
+				DefineTokenInfo(types.NOT, "not", "not").
+				DefineTokenInfo(types.LBR, "{", "left bracket").
+				DefineTokenInfo(types.RBR, "}", "right bracket").
+				DefineTokenInfo(types.DQUOTE, "\"", "double inverted comma").
+				DefineTokenInfo(types.SQUOTE, "'", "single inverted comma")
+			Expect(stoc.SearchStringCustom(defs, "lazy and not {fox or dog}", "the lazy cat")).To(BeTrue())
+
+			_, err := stoc.Format(defs, LexIntoTokens("lazy & fox"))
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(HaveSuffix(" is not known, define the syntax with a types.Definition"))
+		})
+	})
+
+	Describe("round trip", func() {
+		It("should lex the condition corpus back to the same tokens", func() {
+			for _, condition := range conditionCorpus {
+				prepared := LexIntoTokens(condition)
+				formatted, err := stoc.Format(types.DefaultTokensDefinition, prepared)
+				Expect(err).To(BeNil())
+				Expect(Format(types.DefaultTokensDefinition, formatted)).To(Equal(formatted), condition)
+
+				relexed := LexIntoTokens(formatted)
+				for _, target := range targetCorpus {
+					Expect(stoc.SearchTokens(relexed, target)).To(Equal(stoc.SearchTokens(prepared, target)), condition)
+				}
+			}
+		})
+
+		It("should lex random conditions back to equivalent conditions in any syntax", func() {
+			rnd := rand.New(rand.NewSource(32))
+			targets := randomTargets(rnd, 64)
+			for _, defs := range []types.TokensDefinition{types.DefaultTokensDefinition, wordsTokensDefinition} {
+				for i := 0; i < 500; i++ {
+					prepared := randomTokens(rnd, 5)
+					formatted, err := stoc.Format(defs, prepared)
+					Expect(err).To(BeNil())
+
+					relexed, lexErr := stoc.LexIntoTokens(defs, formatted)
+					Expect(lexErr).To(BeNil(), formatted)
+					for _, target := range targets {
+						Expect(stoc.SearchTokens(relexed, target)).To(Equal(stoc.SearchTokens(prepared, target)), formatted)
+					}
+				}
+			}
+		})
+	})
+})
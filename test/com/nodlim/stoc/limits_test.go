@@ -0,0 +1,145 @@
+package com_nodlim_stoc
+
+import (
+	"context"
+	"errors"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/pos_error"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+// limitKind gets the kind of limit exceeded, or an empty kind if err is not a *stoc.LimitError
+func limitKind(err error) stoc.LimitKind {
+	var limitErr *stoc.LimitError
+	if errors.As(err, &limitErr) {
+		return limitErr.Kind
+	}
+	return ""
+}
+
+// countdownContext is cancelled after its Err method has been called remaining times
+type countdownContext struct {
+	context.Context
+	remaining int
+}
+
+func (ctx *countdownContext) Err() error {
+	ctx.remaining--
+	if ctx.remaining < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+/**
+ * BDD Tests
+ */
+var _ = Describe("Search with a context and limits", func() {
+	Describe("the condition corpus", func() {
+		It("should agree with SearchString", func() {
+			for _, condition := range conditionCorpus {
+				for _, target := range targetCorpus {
+					success, err := stoc.SearchStringContext(context.Background(), condition, target, stoc.Limits{})
+					Expect(err).To(BeNil())
+					Expect(success).To(Equal(SearchString(condition, target)), condition)
+				}
+			}
+		})
+	})
+
+	Describe("target size limit", func() {
+		It("should return an error over the limit", func() {
+			_, err := stoc.SearchStringContext(context.Background(), "fox", shortTargetProse, stoc.Limits{MaxTargetSize: 10})
+			Expect(limitKind(err)).To(Equal(stoc.TargetSizeLimit))
+			Expect(err.Error()).To(Equal("limit exceeded, target size of 34 is over the maximum of 10"))
+		})
+
+		It("should search at the limit", func() {
+			success, err := stoc.SearchStringContext(context.Background(), "fox", shortTargetProse, stoc.Limits{MaxTargetSize: 34})
+			Expect(err).To(BeNil())
+			Expect(success).To(Equal(true))
+		})
+	})
+
+	Describe("terms limit", func() {
+		It("should return an error over the limit", func() {
+			_, err := stoc.SearchStringContext(context.Background(), "lazy & fox | dog", shortTargetProse, stoc.Limits{MaxTerms: 2})
+			Expect(limitKind(err)).To(Equal(stoc.TermsLimit))
+		})
+
+		It("should not count the not symbol as a term", func() {
+			success, err := stoc.SearchStringContext(context.Background(), "!dog & !cat", shortTargetProse, stoc.Limits{MaxTerms: 2})
+			Expect(err).To(BeNil())
+			Expect(success).To(Equal(true))
+		})
+	})
+
+	Describe("depth limit", func() {
+		It("should return an error over the limit", func() {
+			_, err := stoc.SearchStringContext(context.Background(), "lazy & (fox | (dog & cat))", shortTargetProse, stoc.Limits{MaxDepth: 2})
+			Expect(limitKind(err)).To(Equal(stoc.DepthLimit))
+		})
+
+		It("should treat chains and brackets without operators as one level", func() {
+			limits := stoc.Limits{MaxDepth: 2}
+			_, err := stoc.SearchStringContext(context.Background(), "lazy | fox | dog | cat", shortTargetProse, limits)
+			Expect(err).To(BeNil())
+			_, err = stoc.SearchStringContext(context.Background(), "((((lazy)))) & !fox", shortTargetProse, limits)
+			Expect(err).To(BeNil())
+		})
+
+		It("should measure the depth of the condition as it is searched", func() {
+			var limitErr *stoc.LimitError
+			_, err := stoc.SearchStringContext(context.Background(), "a & !(b & c)", shortTargetProse, stoc.Limits{MaxDepth: 1})
+			Expect(errors.As(err, &limitErr)).To(Equal(true))
+			Expect(limitErr.Actual).To(Equal(3))
+			_, err = stoc.SearchStringContext(context.Background(), "!(!(a | b)) | c", shortTargetProse, stoc.Limits{MaxDepth: 1})
+			Expect(errors.As(err, &limitErr)).To(Equal(true))
+			Expect(limitErr.Actual).To(Equal(2))
+		})
+
+		It("should reject a deeply nested condition before building it", func() {
+			condition := strings.Repeat("(", 20000) + "c" + strings.Repeat(" | b) & a", 20000)
+			preparedTokens, err := stoc.LexIntoTokens(types.DefaultTokensDefinition, condition)
+			Expect(err).To(BeNil())
+			_, searchErr := stoc.SearchTokensContext(context.Background(), preparedTokens, shortTargetProse, stoc.Limits{MaxDepth: 10})
+			Expect(limitKind(searchErr)).To(Equal(stoc.DepthLimit))
+			_, searchErr = stoc.SearchTokensContext(context.Background(), preparedTokens, shortTargetProse, stoc.Limits{MaxTerms: 10})
+			Expect(limitKind(searchErr)).To(Equal(stoc.TermsLimit))
+		})
+	})
+
+	Describe("invalid condition", func() {
+		It("should return the lexing error", func() {
+			_, err := stoc.SearchStringContext(context.Background(), "foo &", shortTargetProse, stoc.Limits{})
+			var posErr pos_error.PosError
+			Expect(errors.As(err, &posErr)).To(Equal(true))
+		})
+	})
+
+	Describe("cancellation", func() {
+		It("should not search with a cancelled context", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := stoc.SearchStringContext(ctx, "fox", shortTargetProse, stoc.Limits{})
+			Expect(err).To(Equal(context.Canceled))
+		})
+
+		It("should stop searching a huge target once cancelled", func() {
+			ctx := &countdownContext{Context: context.Background(), remaining: 3}
+			_, err := stoc.SearchStringContext(ctx, "b", strings.Repeat("a", 8<<20), stoc.Limits{})
+			Expect(err).To(Equal(context.Canceled))
+			Expect(ctx.remaining).To(Equal(-1))
+		})
+
+		It("should find terms spanning the windows checked between cancellations", func() {
+			target := strings.Repeat("a", 1<<20-2) + "needle" + strings.Repeat("a", 1<<20)
+			success, err := stoc.SearchStringContext(context.Background(), "needle", target, stoc.Limits{})
+			Expect(err).To(BeNil())
+			Expect(success).To(Equal(true))
+		})
+	})
+})
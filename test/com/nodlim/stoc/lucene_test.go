@@ -0,0 +1,140 @@
+package com_nodlim_stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// Compiled is the token types of prepared tokens, with the text of expressions, ignoring how operators were written
+func Compiled(tokens stoc.PreparedTokens) []string {
+	compiled := make([]string, len(tokens))
+	for i, tok := range tokens {
+		compiled[i] = string(tok.Typ)
+		if tok.Typ == types.EXP {
+			compiled[i] += " " + tok.Exp
+		}
+	}
+	return compiled
+}
+
+// LexLucene compiles the Lucene query, we just want the compiled form
+func LexLucene(query string) []string {
+	tokens, err := stoc.LexLuceneIntoTokens(query)
+	Expect(err).To(BeNil(), query)
+	return Compiled(tokens)
+}
+
+// LexLuceneError compiles the Lucene query, we just want the error and its position
+func LexLuceneError(query string) (string, int) {
+	_, err := stoc.LexLuceneIntoTokens(query)
+	Expect(err).NotTo(BeNil(), query)
+	return err.Error(), err.GetPos()
+}
+
+/**
+ * Lucene Tests
+ */
+var _ = Describe("Lucene queries", func() {
+	It("should compile to the same tokens as the equivalent condition", func() {
+		equivalents := map[string]string{
+			"lazy":                            "lazy",
+			"lazy AND fox":                    "lazy & fox",
+			"lazy && fox":                     "lazy & fox",
+			"lazy fox":                        "lazy & fox",
+			"lazy fox dog":                    "lazy & fox & dog",
+			"lazy OR fox":                     "lazy | fox",
+			"lazy || fox":                     "lazy | fox",
+			"NOT lazy":                        "!lazy",
+			"-lazy":                           "!lazy",
+			"!lazy":                           "!lazy",
+			"+lazy":                           "lazy",
+			"lazy AND NOT fox":                "lazy &! fox",
+			"lazy -fox":                       "lazy &! fox",
+			"lazy OR NOT fox":                 "lazy |! fox",
+			"lazy OR fox dog":                 "lazy | (fox & dog)",
+			"lazy fox OR dog":                 "lazy & fox | dog",
+			"(lazy OR fox) dog":               "(lazy | fox) & dog",
+			"-(lazy OR fox)":                  "!(lazy | fox)",
+			"!(lazy OR fox)":                  "!(lazy | fox)",
+			"lazy !fox":                       "lazy &! fox",
+			"\"the lazy dog\" -\"big cat\"":   "'the lazy dog' &! 'big cat'",
+			"\"the lazy dog\" !\"big cat\"":   "'the lazy dog' &! 'big cat'",
+			"\"AND\" OR \"a (b)\"":            "'AND' | 'a (b)'",
+			"lazy -fox OR -dog cat":           "lazy &! fox | (!dog & cat)",
+			"((lazy))":                        "lazy",
+			"and or not":                      "'and' & 'or' & 'not'",
+			"author:\"the kranz\" title:stoc": "'author:\"the kranz\"' & 'title:stoc'",
+		}
+		for query, condition := range equivalents {
+			Expect(LexLucene(query)).To(Equal(Compiled(LexIntoTokens(condition))), query)
+		}
+	})
+
+	It("should apply escapes", func() {
+		Expect(LexLucene(`\AND`)).To(Equal([]string{"EXPRESSION AND"}))
+		Expect(LexLucene(`a\ b`)).To(Equal([]string{"EXPRESSION a b"}))
+		Expect(LexLucene(`\-a`)).To(Equal([]string{"EXPRESSION -a"}))
+		Expect(LexLucene(`"say \"hi\""`)).To(Equal([]string{"EXPRESSION say \"hi\""}))
+		Expect(LexLucene(`\(x\)`)).To(Equal([]string{"EXPRESSION (x)"}))
+	})
+
+	It("should report errors with positions", func() {
+		message, pos := LexLuceneError("")
+		Expect(message).To(Equal("lucene error, expected a term but found end of query"))
+		Expect(pos).To(Equal(0))
+
+		message, pos = LexLuceneError("lazy AND")
+		Expect(message).To(Equal("lucene error, expected a term but found end of query"))
+		Expect(pos).To(Equal(8))
+
+		message, pos = LexLuceneError("lazy OR OR fox")
+		Expect(message).To(Equal("lucene error, expected a term but found OR"))
+		Expect(pos).To(Equal(8))
+
+		message, pos = LexLuceneError("dog (lazy OR fox")
+		Expect(message).To(Equal("lucene error, missing closing bracket"))
+		Expect(pos).To(Equal(4))
+
+		message, pos = LexLuceneError("lazy) fox")
+		Expect(message).To(Equal("lucene error, unexpected )"))
+		Expect(pos).To(Equal(4))
+
+		message, pos = LexLuceneError("lazy \"fox")
+		Expect(message).To(Equal("lucene error, missing closing quote"))
+		Expect(pos).To(Equal(5))
+
+		message, pos = LexLuceneError("lazy - fox")
+		Expect(message).To(Equal("lucene error, - must be followed by a term"))
+		Expect(pos).To(Equal(5))
+
+		message, pos = LexLuceneError("lazy !")
+		Expect(message).To(Equal("lucene error, ! must be followed by a term"))
+		Expect(pos).To(Equal(5))
+
+		message, pos = LexLuceneError(`lazy\`)
+		Expect(message).To(Equal(`lucene error, \ must be followed by a character`))
+		Expect(pos).To(Equal(4))
+	})
+
+	It("should search", func() {
+		Expect(stoc.SearchLuceneString("lazy -dog", shortTargetProse)).To(BeTrue())
+		Expect(stoc.SearchLuceneString("lazy dog", shortTargetProse)).To(BeFalse())
+		Expect(stoc.SearchLuceneString("\"lazy fox\" OR dog", shortTargetProse)).To(BeTrue())
+		Expect(stoc.SearchLuceneString("NOT (fox OR dog)", shortTargetProse)).To(BeFalse())
+
+		_, err := stoc.SearchLuceneString("(", shortTargetProse)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should search records by field", func() {
+		tokens, err := stoc.LexLuceneIntoTokens("author:\"the kranz\" -title:draft")
+		Expect(err).To(BeNil())
+		record := stoc.StructFields(struct {
+			Author string
+			Title  string
+		}{"the kranz", "stoc"})
+		Expect(stoc.SearchTokensRecord(tokens, record)).To(BeTrue())
+	})
+})
@@ -0,0 +1,115 @@
+package com_nodlim_stoc
+
+import (
+	"errors"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	stoctypes "github.com/kranzuft/stoc/cmd/com/nodlim/stoc/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// testMacros are macros for the tests, some referring to others
+var testMacros = stoc.Macros{
+	"noise":    "healthcheck | 'connection reset' | @timeouts",
+	"timeout":  "timeout",
+	"timeouts": "@timeout | 'deadline exceeded'",
+	"animals":  "dog | cat",
+	"negated":  "!lazy",
+}
+
+// LexWithMacros compiles the condition with testMacros, we just want the compiled form
+func LexWithMacros(command string) []string {
+	tokens, err := stoc.LexIntoTokensWithMacros(types.DefaultTokensDefinition, command, testMacros)
+	Expect(err).To(BeNil(), command)
+	return Compiled(tokens)
+}
+
+/**
+ * Macro Tests
+ */
+var _ = Describe("Macros", func() {
+	It("should expand references in brackets", func() {
+		expanded := map[string]string{
+			"lazy & @animals":     "lazy & (dog | cat)",
+			"@animals & lazy":     "(dog | cat) & lazy",
+			"lazy &! @animals":    "lazy &! (dog | cat)",
+			"!@animals":           "!(dog | cat)",
+			"(@animals)":          "((dog | cat))",
+			"@negated | fox":      "(!lazy) | fox",
+			"@animals | @animals": "(dog | cat) | (dog | cat)",
+			"error &! @noise":     "error &! (healthcheck | 'connection reset' | ((timeout) | 'deadline exceeded'))",
+			"lazy":                "lazy",
+		}
+		for condition, expected := range expanded {
+			Expect(LexWithMacros(condition)).To(Equal(Compiled(LexIntoTokens(expected))), condition)
+		}
+	})
+
+	It("should keep expressions that are not references", func() {
+		Expect(LexWithMacros("'@animals' | \"@noise\"")).To(Equal([]string{"EXPRESSION @animals", "EXPRESSION @noise", "OR"}))
+		Expect(LexWithMacros("user@example.com | @ | a@animals")).
+			To(Equal([]string{"EXPRESSION user@example.com", "EXPRESSION @", "OR", "EXPRESSION a@animals", "OR"}))
+		Expect(LexWithMacros("@example.com")).To(Equal([]string{"EXPRESSION @example.com"}))
+	})
+
+	It("should search", func() {
+		tokens, err := stoc.LexIntoTokensWithMacros(types.DefaultTokensDefinition, "error &! @noise", testMacros)
+		Expect(err).To(BeNil())
+		Expect(stoc.SearchTokens(tokens, "error: disk full")).To(BeTrue())
+		Expect(stoc.SearchTokens(tokens, "error: context deadline exceeded")).To(BeFalse())
+		Expect(stoc.SearchTokens(tokens, "error: connection reset")).To(BeFalse())
+	})
+
+	It("should report undefined macros where they are referred to", func() {
+		_, err := stoc.LexIntoTokensWithMacros(types.DefaultTokensDefinition, "lazy & @birds", testMacros)
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal("undefined macro @birds, quote it to search for it"))
+		Expect(err.GetPos()).To(Equal(7))
+
+		_, err = stoc.LexIntoTokensWithMacros(types.DefaultTokensDefinition, "@animals", nil)
+		Expect(err).NotTo(BeNil())
+		Expect(err.GetPos()).To(Equal(0))
+	})
+
+	It("should report cycles", func() {
+		cyclic := stoc.Macros{"a": "x | @b", "b": "y & @a", "self": "z | @self"}
+
+		_, err := stoc.LexIntoTokensWithMacros(types.DefaultTokensDefinition, "lazy | @a", cyclic)
+		var macroErr *stoc.MacroError
+		Expect(errors.As(err, &macroErr)).To(BeTrue())
+		Expect(macroErr.Name).To(Equal("b"))
+		Expect(err.Error()).To(Equal("in macro @b, macro cycle, @a refers to @b refers to @a"))
+		Expect(err.GetPos()).To(Equal(4))
+
+		_, err = stoc.LexIntoTokensWithMacros(types.DefaultTokensDefinition, "@self", cyclic)
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal("in macro @self, macro cycle, @self refers to @self"))
+		Expect(err.GetPos()).To(Equal(4))
+	})
+
+	It("should position errors in the condition of the macro", func() {
+		broken := stoc.Macros{"outer": "lazy | @inner", "inner": "dog & (cat"}
+
+		_, err := stoc.LexIntoTokensWithMacros(types.DefaultTokensDefinition, "fox & @outer", broken)
+		var macroErr *stoc.MacroError
+		Expect(errors.As(err, &macroErr)).To(BeTrue())
+		Expect(macroErr.Name).To(Equal("inner"))
+		_, direct := stoc.LexIntoTokens(types.DefaultTokensDefinition, broken["inner"])
+		Expect(direct).NotTo(BeNil())
+		Expect(err.GetPos()).To(Equal(direct.GetPos()))
+		Expect(err.Error()).To(Equal("in macro @inner, " + direct.Error()))
+
+		_, err = stoc.LexIntoTokensWithMacros(types.DefaultTokensDefinition, "fox &", broken)
+		Expect(errors.As(err, &macroErr)).To(BeFalse())
+	})
+
+	It("should use the syntax and options of the definition", func() {
+		defs := withOptions(wordsTokensDefinition, stoctypes.IMPLICIT_AND, stoctypes.COMMENT)
+		macros := stoc.Macros{"animals": "dog or cat # pets", "place": "'the fence'"}
+		tokens, err := stoc.LexIntoTokensWithMacros(defs, "lazy not @animals @place", macros)
+		Expect(err).To(BeNil())
+		Expect(Compiled(tokens)).To(Equal(Compiled(LexIntoTokens("lazy &! (dog | cat) & ('the fence')"))))
+		Expect(stoc.SearchTokens(tokens, shortTargetProse)).To(BeTrue())
+	})
+})
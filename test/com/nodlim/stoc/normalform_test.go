@@ -0,0 +1,146 @@
+package com_nodlim_stoc
+
+import (
+	"errors"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"math/rand"
+)
+
+// ToCNF converts the command and formats the result, we just want the condition
+func ToCNF(command string) string {
+	cnf, _ := stoc.ToCNF(LexIntoTokens(command), 0)
+	condition, _ := cnf.Format(types.DefaultTokensDefinition)
+	return condition
+}
+
+// ToDNF converts the command and formats the result, we just want the condition
+func ToDNF(command string) string {
+	dnf, _ := stoc.ToDNF(LexIntoTokens(command), 0)
+	condition, _ := dnf.Format(types.DefaultTokensDefinition)
+	return condition
+}
+
+/**
+ * Normal Form Tests
+ */
+var _ = Describe("Normal forms of conditions", func() {
+	Describe("conjunctive normal form", func() {
+		It("should distribute or over and", func() {
+			Expect(ToCNF("a | b & c")).To(Equal("a | b & c"))
+			Expect(ToCNF("a | (b & c)")).To(Equal("a | b & (a | c)"))
+			Expect(ToCNF("(a & b) | (c & d)")).To(Equal("a | c & (a | d) & (b | c) & (b | d)"))
+		})
+
+		It("should push inversions onto expressions", func() {
+			Expect(ToCNF("!(a & b)")).To(Equal("!a | !b"))
+			Expect(ToCNF("!(a | b) | c")).To(Equal("!a | c & (!b | c)"))
+		})
+
+		It("should structure the clauses", func() {
+			cnf, err := stoc.ToCNF(LexIntoTokens("a | !(b | c)"), 0)
+			Expect(err).To(BeNil())
+			Expect(*cnf).To(Equal(stoc.NormalForm{Kind: stoc.CNF, Clauses: []stoc.Clause{
+				{{Exp: "a"}, {Exp: "b", Negated: true}},
+				{{Exp: "a"}, {Exp: "c", Negated: true}},
+			}}))
+		})
+
+		It("should remove clauses that are always met or contain other clauses", func() {
+			Expect(ToCNF("(a | b) & (a | b | c)")).To(Equal("a | b"))
+			Expect(ToCNF("(a & !b) | b")).To(Equal("a | b"))
+		})
+	})
+
+	Describe("disjunctive normal form", func() {
+		It("should distribute and over or", func() {
+			Expect(ToDNF("(a | b) & c")).To(Equal("a & c | (b & c)"))
+			Expect(ToDNF("(a | b) & (c | d)")).To(Equal("a & c | (a & d) | (b & c) | (b & d)"))
+		})
+
+		It("should push inversions onto expressions", func() {
+			Expect(ToDNF("!(a | b)")).To(Equal("!a & !b"))
+			Expect(ToDNF("a & !(b & c)")).To(Equal("a & !b | (a & !c)"))
+		})
+
+		It("should structure the clauses", func() {
+			dnf, err := stoc.ToDNF(LexIntoTokens("(a | b) & !c"), 0)
+			Expect(err).To(BeNil())
+			Expect(*dnf).To(Equal(stoc.NormalForm{Kind: stoc.DNF, Clauses: []stoc.Clause{
+				{{Exp: "a"}, {Exp: "c", Negated: true}},
+				{{Exp: "b"}, {Exp: "c", Negated: true}},
+			}}))
+		})
+
+		It("should remove clauses that are never met or contain other clauses", func() {
+			Expect(ToDNF("(a | b) & (a | !b)")).To(Equal("a"))
+		})
+	})
+
+	Describe("constants", func() {
+		It("should have no clauses or one empty clause", func() {
+			cnf, _ := stoc.ToCNF(LexIntoTokens("a | !a"), 0)
+			Expect(cnf.Clauses).To(BeEmpty())
+			cnf, _ = stoc.ToCNF(LexIntoTokens("a & !a"), 0)
+			Expect(cnf.Clauses).To(Equal([]stoc.Clause{{}}))
+
+			dnf, _ := stoc.ToDNF(LexIntoTokens("a | !a"), 0)
+			Expect(dnf.Clauses).To(Equal([]stoc.Clause{{}}))
+			dnf, _ = stoc.ToDNF(LexIntoTokens("a & !a"), 0)
+			Expect(dnf.Clauses).To(BeEmpty())
+		})
+
+		It("should search as constants", func() {
+			for _, convert := range []func(stoc.PreparedTokens, int) (*stoc.NormalForm, error){stoc.ToCNF, stoc.ToDNF} {
+				always, _ := convert(LexIntoTokens("a | !a"), 0)
+				never, _ := convert(LexIntoTokens("a & !a"), 0)
+				for _, target := range []string{"", "a"} {
+					Expect(stoc.SearchTokens(always.Tokens(), target)).To(BeTrue())
+					Expect(stoc.SearchTokens(never.Tokens(), target)).To(BeFalse())
+				}
+			}
+		})
+	})
+
+	Describe("size cap", func() {
+		It("should stop converting once there are too many clauses", func() {
+			command := "(a & b) | (c & d) | (e & f) | (g & h)"
+			_, err := stoc.ToCNF(LexIntoTokens(command), 8)
+			var limitErr *stoc.LimitError
+			Expect(errors.As(err, &limitErr)).To(BeTrue())
+			Expect(limitErr.Kind).To(Equal(stoc.ClausesLimit))
+			Expect(limitErr.Error()).To(Equal("limit exceeded, number of clauses of 16 is over the maximum of 8"))
+
+			cnf, err := stoc.ToCNF(LexIntoTokens(command), 16)
+			Expect(err).To(BeNil())
+			Expect(cnf.Clauses).To(HaveLen(16))
+
+			dnf, err := stoc.ToDNF(LexIntoTokens(command), 1)
+			Expect(err).NotTo(BeNil())
+			dnf, err = stoc.ToDNF(LexIntoTokens(command), 4)
+			Expect(err).To(BeNil())
+			Expect(dnf.Clauses).To(HaveLen(4))
+		})
+	})
+
+	It("should reject invalid tokens", func() {
+		_, err := stoc.ToCNF(stoc.PreparedTokens{{Typ: types.OR, Exp: "|"}}, 0)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should be equivalent to the original condition", func() {
+		random := rand.New(rand.NewSource(38))
+		for i := 0; i < 200; i++ {
+			tokens := randomTokens(random, 4)
+			cnf, err := stoc.ToCNF(tokens, 0)
+			Expect(err).To(BeNil())
+			dnf, err := stoc.ToDNF(tokens, 0)
+			Expect(err).To(BeNil())
+
+			Expect(stoc.Equivalent(tokens, cnf.Tokens())).To(BeTrue())
+			Expect(stoc.Equivalent(tokens, dnf.Tokens())).To(BeTrue())
+		}
+	})
+})
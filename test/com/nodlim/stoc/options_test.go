@@ -0,0 +1,474 @@
+package com_nodlim_stoc
+
+import (
+	"errors"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	stoctypes "github.com/kranzuft/stoc/cmd/com/nodlim/stoc/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"math/rand"
+	"os"
+	"path/filepath"
+)
+
+// caseInsensitiveTokensDefinition is the sql syntax, with keywords in any case
+var caseInsensitiveTokensDefinition, _ = stoc.Preset("sql")
+
+// implicitAndTokensDefinition is the default syntax, with juxtaposed terms joined by and
+var implicitAndTokensDefinition = withOptions(types.DefaultTokensDefinition, stoctypes.IMPLICIT_AND)
+
+// escapeTokensDefinition is the default syntax, with escape sequences
+var escapeTokensDefinition = withOptions(types.DefaultTokensDefinition, stoctypes.ESCAPE)
+
+// commentTokensDefinition is the default syntax, with comments and conditions over many lines
+var commentTokensDefinition = withOptions(types.DefaultTokensDefinition, stoctypes.COMMENT)
+
+// withOptions copies defs, enabling options
+func withOptions(defs types.TokensDefinition, options ...types.TokenType) types.TokensDefinition {
+	copied := types.TokensDefinition{}
+	for typ, info := range defs {
+		copied[typ] = info
+	}
+	for _, option := range options {
+		copied.DefineTokenInfo(option, "", string(option))
+	}
+	return copied
+}
+
+// SearchImplicitAnd searches with implicitAndTokensDefinition, we just want to know if it was found
+func SearchImplicitAnd(command string, target string) bool {
+	success, err := stoc.SearchStringCustom(implicitAndTokensDefinition, command, target)
+	Expect(err).To(BeNil(), command)
+	return success
+}
+
+/**
+ * Option Tests
+ */
+var _ = Describe("Syntax options", func() {
+	Describe("case insensitive keywords", func() {
+		It("should be enabled by the sql preset", func() {
+			Expect(caseInsensitiveTokensDefinition).To(HaveKey(stoctypes.CASE_INSENSITIVE))
+		})
+
+		It("should make keys that differ only in case ambiguous", func() {
+			def := stoctypes.Definition(withOptions(caseInsensitiveTokensDefinition))
+			def.DefineTokenInfo(types.LBR, "a", "left bracket")
+			err := stoc.ValidateTokensDefinition(def.Finalise())
+			Expect(errors.Is(err, stoc.ErrInvalidSyntax)).To(BeTrue())
+			Expect(err.Error()).To(Equal("invalid syntax, ambiguous keys, \"a\" of LEFT_BRACKET is a prefix of \"AND\" of AND"))
+
+			def = stoctypes.Definition(withOptions(caseInsensitiveTokensDefinition))
+			def.DefineTokenInfo(types.AND, "or", "and")
+			err = stoc.ValidateTokensDefinition(def.Finalise())
+			Expect(err.Error()).To(Equal("invalid syntax, duplicate keys regardless of case, \"or\" of AND and \"OR\" of OR"))
+
+			def = stoctypes.Definition(withOptions(caseInsensitiveTokensDefinition, stoctypes.COMMENT))
+			def.DefineTokenInfo(stoctypes.COMMENT, "n", "comment")
+			Expect(errors.Is(stoc.ValidateTokensDefinition(def.Finalise()), stoc.ErrInvalidSyntax)).To(BeTrue())
+
+			// without the option, they are different keys
+			def = stoctypes.Definition(withOptions(caseInsensitiveTokensDefinition))
+			delete(def, stoctypes.CASE_INSENSITIVE)
+			def.DefineTokenInfo(types.AND, "or", "and")
+			Expect(stoc.ValidateTokensDefinition(def.Finalise())).To(Succeed())
+		})
+
+		It("should recognise keywords in any case", func() {
+			expected := Compiled(LexIntoTokens("lazy &! (fox | dog)"))
+			for _, condition := range []string{
+				"lazy AND NOT (fox OR dog)",
+				"lazy and not (fox or dog)",
+				"lazy And Not (fox Or dog)",
+				"lazy aNd nOT (fox oR dog)",
+			} {
+				tokens, err := stoc.LexIntoTokens(caseInsensitiveTokensDefinition, condition)
+				Expect(err).To(BeNil(), condition)
+				Expect(Compiled(tokens)).To(Equal(expected), condition)
+			}
+		})
+
+		It("should keep quoted keywords as expressions", func() {
+			tokens, err := stoc.LexIntoTokens(caseInsensitiveTokensDefinition, "'and' OR \"Not\"")
+			Expect(err).To(BeNil())
+			Expect(Compiled(tokens)).To(Equal([]string{"EXPRESSION and", "EXPRESSION Not", "OR"}))
+		})
+
+		It("should be case sensitive without the option", func() {
+			defs := types.TokensDefinition{}
+			for typ, info := range caseInsensitiveTokensDefinition {
+				if typ != stoctypes.CASE_INSENSITIVE {
+					defs[typ] = info
+				}
+			}
+			tokens, err := stoc.LexIntoTokens(defs, "lazy and fox")
+			Expect(err).To(BeNil())
+			Expect(Compiled(tokens)).To(Equal([]string{"EXPRESSION lazy and fox"}))
+		})
+
+		It("should search", func() {
+			Expect(stoc.SearchStringCustom(caseInsensitiveTokensDefinition, "lazy and not (fox or dog)", "the lazy cat")).To(BeTrue())
+			Expect(stoc.SearchStringCustom(caseInsensitiveTokensDefinition, "lazy and not (fox or dog)", "the lazy fox")).To(BeFalse())
+			Expect(stoc.SearchStringCustom(caseInsensitiveTokensDefinition, "'brand' or 'or'", "the brand")).To(BeTrue())
+		})
+
+		It("should find keywords inside unquoted words, in any case", func() {
+			_, err := stoc.LexIntoTokens(caseInsensitiveTokensDefinition, "Brand")
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("should quote expressions that are keywords in any case when formatting", func() {
+			tokens := stoc.PreparedTokens{{Typ: types.EXP, Exp: "brand"}, {Typ: types.EXP, Exp: "Not"}, {Typ: types.OR, Exp: "|"}}
+			formatted, err := stoc.Format(caseInsensitiveTokensDefinition, tokens)
+			Expect(err).To(BeNil())
+			Expect(formatted).To(Equal("\"brand\" OR \"Not\""))
+
+			relexed, lexErr := stoc.LexIntoTokens(caseInsensitiveTokensDefinition, formatted)
+			Expect(lexErr).To(BeNil())
+			Expect(Compiled(relexed)).To(Equal(Compiled(tokens)))
+		})
+
+		It("should position errors as for the keywords in their defined case", func() {
+			_, expected := stoc.LexIntoTokens(caseInsensitiveTokensDefinition, "lazy AND OR fox")
+			Expect(expected).NotTo(BeNil())
+			_, err := stoc.LexIntoTokens(caseInsensitiveTokensDefinition, "lazy and or fox")
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal(expected.Error()))
+			Expect(err.GetPos()).To(Equal(expected.GetPos()))
+		})
+
+		It("should be kept when saving and loading the syntax", func() {
+			data, err := stoc.MarshalTokensDefinitionYAML(caseInsensitiveTokensDefinition)
+			Expect(err).To(BeNil())
+			defs, err := stoc.UnmarshalTokensDefinitionYAML(data)
+			Expect(err).To(BeNil())
+			Expect(defs).To(HaveKey(stoctypes.CASE_INSENSITIVE))
+		})
+	})
+
+	//
+	// foo bar => foo and bar
+	//
+	Describe("implicit and", func() {
+		Context("in line with A and B", func() {
+			It("should be true", func() {
+				Expect(SearchImplicitAnd("lazy fox", shortTargetProse)).To(Equal(true))
+				Expect(SearchImplicitAnd("\"The\" \"over\"", shortTargetProse)).To(Equal(true))
+				Expect(SearchImplicitAnd("'jumped' 'fence'", shortTargetProse)).To(Equal(true))
+				Expect(SearchImplicitAnd("Programmers \"mistake\"", longTargetProse)).To(Equal(true))
+				Expect(SearchImplicitAnd("∆√∫ 'brevity'", longTargetProse)).To(Equal(true))
+				Expect(SearchImplicitAnd("mistake clarity brevity", longTargetProse)).To(Equal(true))
+			})
+		})
+		Context("in line with A and not B", func() {
+			It("should be false", func() {
+				Expect(SearchImplicitAnd("lazy foxy", shortTargetProse)).To(Equal(false))
+				Expect(SearchImplicitAnd("\"The\" \"overt\"", shortTargetProse)).To(Equal(false))
+				Expect(SearchImplicitAnd("'jumped' 'fencing'", shortTargetProse)).To(Equal(false))
+				Expect(SearchImplicitAnd("Programmers \"mistook\"", longTargetProse)).To(Equal(false))
+				Expect(SearchImplicitAnd("∆√∫ 'brave'", longTargetProse)).To(Equal(false))
+				Expect(SearchImplicitAnd("mistake clarity bravery", longTargetProse)).To(Equal(false))
+			})
+		})
+		Context("in line with not A and B", func() {
+			It("should be false", func() {
+				Expect(SearchImplicitAnd("lazyish fox", shortTargetProse)).To(Equal(false))
+				Expect(SearchImplicitAnd("\"Then\" \"over\"", shortTargetProse)).To(Equal(false))
+				Expect(SearchImplicitAnd("'jumpy' 'fence'", shortTargetProse)).To(Equal(false))
+				Expect(SearchImplicitAnd("Programmered \"mistake\"", longTargetProse)).To(Equal(false))
+			})
+		})
+
+		It("should compile to the same tokens as explicit and", func() {
+			equivalents := map[string]string{
+				"lazy fox":           "lazy & fox",
+				"lazy   fox\tdog":    "lazy & fox & dog",
+				"'the lazy' \"fox\"": "'the lazy' & fox",
+				"lazy !dog":          "lazy &! dog",
+				"lazy !(dog | cat)":  "lazy &! (dog | cat)",
+				"(lazy | dog) fence": "(lazy | dog) & fence",
+				"fox (dog | fence)":  "fox & (dog | fence)",
+				"(lazy)(fox)":        "(lazy) & (fox)",
+				"lazy fox | dog":     "lazy & fox | dog",
+				"lazy | fox dog":     "lazy | fox & dog",
+				"lazy & fox":         "lazy & fox",
+				"!lazy fox":          "!lazy & fox",
+				"'a' 'b''c'":         "'a' & 'b' & 'c'",
+				"lazy":               "lazy",
+			}
+			for condition, explicit := range equivalents {
+				tokens, err := stoc.LexIntoTokens(implicitAndTokensDefinition, condition)
+				Expect(err).To(BeNil(), condition)
+				Expect(Compiled(tokens)).To(Equal(Compiled(LexIntoTokens(explicit))), condition)
+			}
+		})
+
+		It("should keep phrases that are quoted", func() {
+			tokens, err := stoc.LexIntoTokens(implicitAndTokensDefinition, "'lazy fox' \"the fence\"")
+			Expect(err).To(BeNil())
+			Expect(Compiled(tokens)).To(Equal([]string{"EXPRESSION lazy fox", "EXPRESSION the fence", "AND"}))
+			Expect(SearchImplicitAnd("'lazy fox'", shortTargetProse)).To(Equal(true))
+			Expect(SearchImplicitAnd("'fox lazy'", shortTargetProse)).To(Equal(false))
+			Expect(SearchImplicitAnd("fox lazy", shortTargetProse)).To(Equal(true))
+		})
+
+		It("should be a phrase without the option", func() {
+			Expect(Compiled(LexIntoTokens("lazy fox"))).To(Equal([]string{"EXPRESSION lazy fox"}))
+		})
+
+		It("should join words with the and keyword of the syntax", func() {
+			defs := withOptions(wordsTokensDefinition, stoctypes.IMPLICIT_AND)
+			Expect(stoc.SearchStringCustom(defs, "lazy fox not dog", shortTargetProse)).To(BeTrue())
+			Expect(stoc.SearchStringCustom(defs, "lazy {dog or cat}", shortTargetProse)).To(BeFalse())
+		})
+
+		It("should position errors in the condition", func() {
+			_, err := stoc.LexIntoTokens(implicitAndTokensDefinition, "lazy fox dog & )")
+			Expect(err).NotTo(BeNil())
+			_, explicit := stoc.LexIntoTokens(types.DefaultTokensDefinition, "lazy&fox&dog & )")
+			Expect(explicit).NotTo(BeNil())
+			Expect(err.Error()).To(Equal(explicit.Error()))
+			Expect(err.GetPos()).To(Equal(explicit.GetPos()))
+		})
+
+		It("should position findings in the condition", func() {
+			findings, err := stoc.Analyze(implicitAndTokensDefinition, "x | (foo !foo)")
+			Expect(err).To(BeNil())
+			Expect(findings).To(Equal([]stoc.Finding{{Kind: stoc.AlwaysFalse, Start: 4, End: 14, Text: "(foo !foo)"}}))
+		})
+
+		It("should quote phrases when formatting", func() {
+			tokens := LexIntoTokens("'lazy fox' & dog")
+			formatted, err := stoc.Format(implicitAndTokensDefinition, tokens)
+			Expect(err).To(BeNil())
+			Expect(formatted).To(Equal("\"lazy fox\" & dog"))
+
+			relexed, lexErr := stoc.LexIntoTokens(implicitAndTokensDefinition, formatted)
+			Expect(lexErr).To(BeNil())
+			Expect(Compiled(relexed)).To(Equal(Compiled(tokens)))
+		})
+	})
+
+	Describe("escape sequences", func() {
+		It("should apply escapes in quoted and bare expressions", func() {
+			escaped := map[string]string{
+				`"say \"hi\""`:      `say "hi"`,
+				`'it\'s'`:           `it's`,
+				`"it's \"quoted\""`: `it's "quoted"`,
+				`a\&b`:              `a&b`,
+				`\!lazy`:            `!lazy`,
+				`a\|b\(c\)`:         `a|b(c)`,
+				`"back\\slash"`:     `back\slash`,
+				`back\\slash`:       `back\slash`,
+				`tab\tline\nbreak`:  "tab\tline\nbreak",
+				`\u00e9t\u00E9`:     "été",
+				`"\u0026"`:          "&",
+				`\ padded\ `:        " padded ",
+				`\z`:                "z",
+			}
+			for condition, exp := range escaped {
+				tokens, err := stoc.LexIntoTokens(escapeTokensDefinition, condition)
+				Expect(err).To(BeNil(), condition)
+				Expect(Compiled(tokens)).To(Equal([]string{"EXPRESSION " + exp}), condition)
+			}
+		})
+
+		It("should keep escaped keywords out of operators", func() {
+			tokens, err := stoc.LexIntoTokens(escapeTokensDefinition, `a\&b & \(c\) |! "\"d"`)
+			Expect(err).To(BeNil())
+			Expect(Compiled(tokens)).To(Equal([]string{"EXPRESSION a&b", "EXPRESSION (c)", "AND", "EXPRESSION \"d", "ORNOT"}))
+		})
+
+		It("should not apply escapes without the option", func() {
+			tokens, err := stoc.LexIntoTokens(types.DefaultTokensDefinition, `a\&b`)
+			Expect(err).To(BeNil())
+			Expect(Compiled(tokens)).To(Equal([]string{"EXPRESSION a\\", "EXPRESSION b", "AND"}))
+		})
+
+		It("should search", func() {
+			Expect(stoc.SearchStringCustom(escapeTokensDefinition, `fish\&chips & "\"best\""`, `fish&chips, the "best"`)).To(BeTrue())
+			Expect(stoc.SearchStringCustom(escapeTokensDefinition, `fish\&chips`, `fish and chips`)).To(BeFalse())
+		})
+
+		It("should position errors", func() {
+			_, err := stoc.LexIntoTokens(escapeTokensDefinition, `lazy & fox\`)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal(`escape error, \ must be followed by a character`))
+			Expect(err.GetPos()).To(Equal(10))
+
+			_, err = stoc.LexIntoTokens(escapeTokensDefinition, `lazy & \u12g4`)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal(`escape error, \u must be followed by four hexadecimal digits`))
+			Expect(err.GetPos()).To(Equal(7))
+
+			_, err = stoc.LexIntoTokens(escapeTokensDefinition, `"lazy\"`)
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("should reject surrogates", func() {
+			for _, surrogate := range []string{`\uD800`, `\udbff`, `\uDC00`, `\uDFFF`} {
+				_, err := stoc.LexIntoTokens(escapeTokensDefinition, "lazy & "+surrogate)
+				Expect(err).NotTo(BeNil(), surrogate)
+				Expect(err.Error()).To(Equal(`escape error, ` + surrogate + ` is a surrogate, not a character`))
+				Expect(err.GetPos()).To(Equal(7))
+			}
+			tokens, err := stoc.LexIntoTokens(escapeTokensDefinition, `\uD7FF | \uE000`)
+			Expect(err).To(BeNil())
+			Expect(Compiled(tokens)).To(Equal([]string{"EXPRESSION \uD7FF", "EXPRESSION \uE000", "OR"}))
+		})
+
+		It("should position findings in the condition as written", func() {
+			findings, err := stoc.Analyze(escapeTokensDefinition, `x | ("a\"" & !"a\"")`)
+			Expect(err).To(BeNil())
+			Expect(findings).To(Equal([]stoc.Finding{{Kind: stoc.AlwaysFalse, Start: 4, End: 20, Text: `("a\"" & !"a\"")`}}))
+		})
+
+		It("should combine with implicit and", func() {
+			defs := withOptions(types.DefaultTokensDefinition, stoctypes.ESCAPE, stoctypes.IMPLICIT_AND)
+			tokens, err := stoc.LexIntoTokens(defs, `say\ hi "the \"end\"" \!`)
+			Expect(err).To(BeNil())
+			Expect(Compiled(tokens)).To(Equal([]string{"EXPRESSION say hi", "EXPRESSION the \"end\"", "AND", "EXPRESSION !", "AND"}))
+		})
+
+		It("should escape when formatting", func() {
+			tokens := stoc.PreparedTokens{{Typ: types.EXP, Exp: `"hi" it's`}, {Typ: types.EXP, Exp: `back\slash`}, {Typ: types.OR, Exp: "|"}}
+			_, err := stoc.Format(types.DefaultTokensDefinition, tokens)
+			Expect(err).NotTo(BeNil())
+
+			formatted, err := stoc.Format(escapeTokensDefinition, tokens)
+			Expect(err).To(BeNil())
+			Expect(formatted).To(Equal(`"\"hi\" it's" | back\\slash`))
+
+			relexed, lexErr := stoc.LexIntoTokens(escapeTokensDefinition, formatted)
+			Expect(lexErr).To(BeNil())
+			Expect(Compiled(relexed)).To(Equal(Compiled(tokens)))
+		})
+
+		It("should round trip random conditions through Format", func() {
+			rnd := rand.New(rand.NewSource(48))
+			for i := 0; i < 200; i++ {
+				tokens := randomTokens(rnd, 3)
+				formatted, err := stoc.Format(escapeTokensDefinition, tokens)
+				Expect(err).To(BeNil())
+				relexed, lexErr := stoc.LexIntoTokens(escapeTokensDefinition, formatted)
+				Expect(lexErr).To(BeNil(), formatted)
+				for _, target := range targetCorpus {
+					Expect(stoc.SearchTokens(relexed, target)).To(Equal(stoc.SearchTokens(tokens, target)), formatted)
+				}
+			}
+		})
+
+		It("should not allow keys containing the escape", func() {
+			defs := withOptions(types.DefaultTokensDefinition, stoctypes.ESCAPE)
+			defs.DefineTokenInfo(types.OR, `\/`, "or")
+			Expect(errors.Is(stoc.ValidateTokensDefinition(defs), stoc.ErrInvalidSyntax)).To(BeTrue())
+		})
+	})
+
+	Describe("comments and many lines", func() {
+		It("should ignore comments", func() {
+			commented := map[string]string{
+				"lazy # the dog is not":             "lazy",
+				"# find the lazy\nlazy":             "lazy",
+				"lazy &\n# not dogs\n!dog":          "lazy &! dog",
+				"lazy | # or\n\tfox # the fox\n":    "lazy | fox",
+				"C# & 'a # b' & \"c # d\"":          "'C#' & 'a # b' & 'c # d'",
+				"(lazy # comment with ) and &\n)":   "lazy",
+				"lazy\r\n& fox\r\n":                 "lazy & fox",
+				"'a\r\nb'":                          "'a\r\nb'",
+				"the lazy\n  fox\n& dog":            "'the lazy fox' & dog",
+				"the\tlazy\n\n   # comment\n   fox": "'the\tlazy fox'",
+				"'the lazy\n  fox'":                 "'the lazy\n  fox'",
+			}
+			for condition, expected := range commented {
+				tokens, err := stoc.LexIntoTokens(commentTokensDefinition, condition)
+				Expect(err).To(BeNil(), condition)
+				Expect(Compiled(tokens)).To(Equal(Compiled(LexIntoTokens(expected))), condition)
+			}
+		})
+
+		It("should find conditions of only comments empty", func() {
+			for _, condition := range []string{"# nothing", "# a\n# b\n", "  \n\t", "# a\r\n"} {
+				_, err := stoc.LexIntoTokens(commentTokensDefinition, condition)
+				Expect(err).NotTo(BeNil(), condition)
+				Expect(err.Error()).To(Equal("Empty search text"))
+				Expect(err.GetPos()).To(Equal(0))
+			}
+
+			path := filepath.Join("testdata", "conditions", "disabled.stoc")
+			_, err := stoc.LexFileIntoTokens(commentTokensDefinition, path)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal(path + ":1:1: Empty search text"))
+		})
+
+		It("should not have comments without the option", func() {
+			Expect(Compiled(LexIntoTokens("lazy # fox"))).To(Equal([]string{"EXPRESSION lazy # fox"}))
+		})
+
+		It("should use the key of the option", func() {
+			def := stoctypes.Definition(withOptions(types.DefaultTokensDefinition))
+			defs := def.DefineTokenInfo(stoctypes.COMMENT, "//", "comment").Finalise()
+			tokens, err := stoc.LexIntoTokens(defs, "lazy // # is not a comment here\n# but this is")
+			Expect(err).To(BeNil())
+			Expect(Compiled(tokens)).To(Equal([]string{"EXPRESSION lazy # but this is"}))
+			Expect(stoc.ValidateTokensDefinition(defs)).To(Succeed())
+
+			def.DefineTokenInfo(stoctypes.COMMENT, "|", "comment")
+			Expect(errors.Is(stoc.ValidateTokensDefinition(defs), stoc.ErrInvalidSyntax)).To(BeTrue())
+		})
+
+		It("should position errors and findings in the condition", func() {
+			_, err := stoc.LexIntoTokens(commentTokensDefinition, "lazy # ) & |\n& )")
+			Expect(err).NotTo(BeNil())
+			_, uncommented := stoc.LexIntoTokens(types.DefaultTokensDefinition, "lazy        \n& )")
+			Expect(err.GetPos()).To(Equal(uncommented.GetPos()))
+
+			findings, analyzeErr := stoc.Analyze(commentTokensDefinition, "x # (foo & !foo)\n| (foo # a\n& !foo)")
+			Expect(analyzeErr).To(BeNil())
+			Expect(findings).To(Equal([]stoc.Finding{{Kind: stoc.AlwaysFalse, Start: 19, End: 35, Text: "(foo # a\n& !foo)"}}))
+		})
+
+		It("should combine with implicit and escapes", func() {
+			defs := withOptions(types.DefaultTokensDefinition, stoctypes.COMMENT, stoctypes.IMPLICIT_AND, stoctypes.ESCAPE)
+			tokens, err := stoc.LexIntoTokens(defs, "error  # errors\ntimeout \\# # and timeouts\n")
+			Expect(err).To(BeNil())
+			Expect(Compiled(tokens)).To(Equal([]string{"EXPRESSION error", "EXPRESSION timeout", "AND", "EXPRESSION #", "AND"}))
+		})
+
+		It("should quote expressions with comments or line breaks when formatting", func() {
+			tokens := stoc.PreparedTokens{{Typ: types.EXP, Exp: "C# #1"}, {Typ: types.EXP, Exp: "two\nlines"}, {Typ: types.OR, Exp: "|"}}
+			formatted, err := stoc.Format(commentTokensDefinition, tokens)
+			Expect(err).To(BeNil())
+			Expect(formatted).To(Equal("\"C# #1\" | \"two\nlines\""))
+
+			relexed, lexErr := stoc.LexIntoTokens(commentTokensDefinition, formatted)
+			Expect(lexErr).To(BeNil())
+			Expect(Compiled(relexed)).To(Equal(Compiled(tokens)))
+		})
+
+		It("should read conditions from files", func() {
+			tokens, err := stoc.LexFileIntoTokens(commentTokensDefinition, filepath.Join("testdata", "conditions", "noise.stoc"))
+			Expect(err).To(BeNil())
+			Expect(Compiled(tokens)).To(Equal(Compiled(LexIntoTokens(
+				"(connection reset | 'broken pipe' | 'deadline exceeded' | '# not a comment') &! ERROR"))))
+
+			Expect(stoc.SearchTokens(tokens, "read: connection reset by peer")).To(BeTrue())
+			Expect(stoc.SearchTokens(tokens, "ERROR: context deadline exceeded")).To(BeFalse())
+		})
+
+		It("should give the line and column of errors in files", func() {
+			path := filepath.Join("testdata", "conditions", "invalid.stoc")
+			_, err := stoc.LexFileIntoTokens(commentTokensDefinition, path)
+			var fileErr *stoc.ConditionFileError
+			Expect(errors.As(err, &fileErr)).To(BeTrue())
+			Expect(fileErr.Path).To(Equal(path))
+			Expect(fileErr.Line).To(Equal(3))
+			Expect(fileErr.Column).To(Equal(1))
+			Expect(err.Error()).To(HavePrefix(path + ":3:1: "))
+
+			_, err = stoc.LexFileIntoTokens(commentTokensDefinition, filepath.Join("testdata", "conditions", "missing.stoc"))
+			Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+		})
+	})
+})
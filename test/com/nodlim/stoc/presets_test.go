@@ -0,0 +1,119 @@
+package com_nodlim_stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// expectPresetsAgree translates command, in the default syntax, into each preset, and expects it to find target in
+// each as it does in the default syntax
+func expectPresetsAgree(command string, target string, expected bool) {
+	for _, name := range stoc.PresetNames() {
+		defs, _ := stoc.Preset(name)
+		translated, err := stoc.Translate(types.DefaultTokensDefinition, defs, command)
+		Expect(err).To(BeNil(), name+": "+command)
+		Expect(stoc.SearchStringCustom(defs, translated, target)).To(Equal(expected), name+": "+translated)
+	}
+}
+
+/**
+ * Preset Tests
+ */
+var _ = Describe("Syntax presets", func() {
+	It("should list the built in presets", func() {
+		Expect(stoc.PresetNames()).To(ContainElements("c", "english", "french", "german", "spanish", "sql", "symbolic"))
+
+		symbolic, ok := stoc.Preset("symbolic")
+		Expect(ok).To(BeTrue())
+		Expect(symbolic).To(Equal(types.DefaultTokensDefinition))
+
+		english, ok := stoc.Preset("english")
+		Expect(ok).To(BeTrue())
+		Expect(english).To(Equal(wordsTokensDefinition))
+
+		_, ok = stoc.Preset("klingon")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should search with each preset", func() {
+		examples := map[string]string{
+			"english": "lazy and not {fox or dog}",
+			"sql":     "lazy AND NOT (fox OR dog)",
+			"c":       "lazy && !(fox || dog)",
+			"german":  "lazy und nicht (fox oder dog)",
+			"french":  "lazy et non (fox ou dog)",
+			"spanish": "'lazy' y no ('fox' o 'dog')",
+		}
+		for name, condition := range examples {
+			defs, _ := stoc.Preset(name)
+			Expect(stoc.SearchStringCustom(defs, condition, "the lazy cat")).To(BeTrue(), name)
+			Expect(stoc.SearchStringCustom(defs, condition, "the lazy fox")).To(BeFalse(), name)
+		}
+	})
+
+	It("should quote expressions containing the keywords of a preset when translating into it", func() {
+		spanish, _ := stoc.Preset("spanish")
+		Expect(Translate(types.DefaultTokensDefinition, spanish, "lazy & !(fox | dog)")).
+			To(Equal("\"lazy\" y no (\"fox\" o \"dog\")"))
+		french, _ := stoc.Preset("french")
+		Expect(Translate(types.DefaultTokensDefinition, french, "bet | trout")).To(Equal("\"bet\" ou \"trout\""))
+	})
+
+	It("should be valid", func() {
+		for _, name := range stoc.PresetNames() {
+			defs, _ := stoc.Preset(name)
+			Expect(stoc.ValidateTokensDefinition(defs)).To(Succeed(), name)
+		}
+	})
+
+	It("should give the same results as the default syntax over the condition corpus", func() {
+		// the search tests check the presets for each of their conditions too, see SearchString
+		for _, condition := range conditionCorpus {
+			for _, target := range targetCorpus {
+				expected, _ := stoc.SearchString(condition, target)
+				expectPresetsAgree(condition, target, expected)
+			}
+		}
+	})
+
+	It("should have case insensitive keywords in the sql preset", func() {
+		defs, _ := stoc.Preset("sql")
+		for _, condition := range []string{"lazy and not (fox or dog)", "lazy And Not (fox Or dog)"} {
+			Expect(stoc.SearchStringCustom(defs, condition, "the lazy cat")).To(BeTrue(), condition)
+			Expect(stoc.SearchStringCustom(defs, condition, "the lazy fox")).To(BeFalse(), condition)
+		}
+		Expect(stoc.SearchStringCustom(defs, "'and'", "this and that")).To(BeTrue())
+	})
+
+	It("should not be changed by changing the syntax returned", func() {
+		symbolic, _ := stoc.Preset("symbolic")
+		delete(symbolic, types.AND)
+		symbolic.DefineTokenInfo(types.OR, "/", "or")
+		Expect(stoc.SearchString("lazy & fox | dog", shortTargetProse)).To(BeTrue())
+		again, _ := stoc.Preset("symbolic")
+		Expect(again).To(Equal(types.DefaultTokensDefinition))
+	})
+
+	It("should register presets", func() {
+		defs, err := stoc.LoadTokensDefinition("testdata/syntax/words.json")
+		Expect(err).To(BeNil())
+		Expect(stoc.RegisterPreset("test words", defs)).To(Succeed())
+		registered, ok := stoc.Preset("test words")
+		Expect(ok).To(BeTrue())
+		Expect(registered).To(Equal(defs))
+
+		err = stoc.RegisterPreset("test words", defs)
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal("cannot register syntax, a syntax is already registered as \"test words\""))
+		Expect(stoc.RegisterPreset("test invalid", types.TokensDefinition{})).NotTo(Succeed())
+		_, ok = stoc.Preset("test invalid")
+		Expect(ok).To(BeFalse())
+
+		// the registered syntax is a copy
+		defs.DefineTokenInfo(types.AND, "plus", "and")
+		registered, _ = stoc.Preset("test words")
+		Expect(registered).NotTo(Equal(defs))
+	})
+})
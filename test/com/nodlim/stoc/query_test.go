@@ -0,0 +1,73 @@
+package com_nodlim_stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+/**
+ * BDD Tests
+ */
+var _ = Describe("Build queries in code", func() {
+	Describe("compiled form", func() {
+		It("should be the same as LexIntoTokens", func() {
+			pairs := map[string]stoc.Query{
+				"foo":              stoc.Term("foo"),
+				"!foo":             stoc.Not(stoc.Term("foo")),
+				"foo&bar":          stoc.Term("foo").And(stoc.Term("bar")),
+				"foo|bar":          stoc.Term("foo").Or(stoc.Term("bar")),
+				"foo&!bar":         stoc.Term("foo").And(stoc.Not(stoc.Term("bar"))),
+				"foo|!bar":         stoc.Term("foo").OrNot(stoc.Term("bar")),
+				"foo&(bar|baz)":    stoc.Term("foo").And(stoc.Term("bar").Or(stoc.Term("baz"))),
+				"!(foo|bar)&baz":   stoc.Not(stoc.Term("foo").Or(stoc.Term("bar"))).And(stoc.Term("baz")),
+				"!(!foo)":          stoc.Not(stoc.Not(stoc.Term("foo"))),
+				"foo&bar&baz":      stoc.AllOf(stoc.Term("foo"), stoc.Term("bar"), stoc.Term("baz")),
+				"foo|bar|baz":      stoc.AnyOf(stoc.Term("foo"), stoc.Term("bar"), stoc.Term("baz")),
+				"a&!(b|!c)|(d&!e)": stoc.Term("a").AndNot(stoc.Term("b").OrNot(stoc.Term("c"))).Or(stoc.Term("d").AndNot(stoc.Term("e"))),
+			}
+			for condition, query := range pairs {
+				Expect(query.Tokens()).To(Equal(LexIntoTokens(condition)), condition)
+			}
+		})
+	})
+
+	Describe("escaping", func() {
+		It("should take terms literally", func() {
+			query := stoc.Term("a & b").Or(stoc.Not(stoc.Term("(it's)")))
+			Expect(stoc.SearchTokens(query.Tokens(), "x a & b y")).To(Equal(true))
+			Expect(stoc.SearchTokens(query.Tokens(), "a")).To(Equal(true))
+			Expect(stoc.SearchTokens(query.Tokens(), "(it's)")).To(Equal(false))
+		})
+
+		It("should quote terms when rendered", func() {
+			query := stoc.Term("a & b").Or(stoc.Not(stoc.Term("(it's)"))).And(stoc.Term("say \"hi\" !"))
+			condition, err := query.Format(types.DefaultTokensDefinition)
+			Expect(err).To(BeNil())
+			Expect(condition).To(Equal("\"a & b\" | !\"(it's)\" & 'say \"hi\" !'"))
+
+			condition, err = query.Format(wordsTokensDefinition)
+			Expect(err).To(BeNil())
+			Expect(condition).To(Equal("a & b or not (it's) and say \"hi\" !"))
+		})
+	})
+
+	Describe("empty queries", func() {
+		It("should be met by every target when all are required", func() {
+			Expect(stoc.SearchTokens(stoc.AllOf().Tokens(), "")).To(Equal(true))
+		})
+
+		It("should be met by no target when any is required", func() {
+			Expect(stoc.SearchTokens(stoc.AnyOf().Tokens(), shortTargetProse)).To(Equal(false))
+		})
+
+		It("should be ignored when joined", func() {
+			Expect(stoc.Term("foo").And(stoc.Query{}).Tokens()).To(Equal(LexIntoTokens("foo")))
+			Expect(stoc.Not(stoc.Query{}).Or(stoc.Term("foo")).Tokens()).To(Equal(LexIntoTokens("foo")))
+			Expect(stoc.Query{}.Tokens()).To(BeNil())
+			_, err := stoc.Query{}.Format(types.DefaultTokensDefinition)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
@@ -0,0 +1,136 @@
+package com_nodlim_stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+// Test data
+type post struct {
+	Author string
+	Title  string `stoc:"heading"`
+	Tags   []string
+	Secret string `stoc:"-"`
+	Likes  int
+	meta
+}
+
+type meta struct {
+	Source string
+}
+
+// upperFields is a hand-written Fields implementation
+type upperFields map[string]string
+
+func (uf upperFields) Lookup(name string) (string, bool) {
+	text, ok := uf[name]
+	return strings.ToUpper(text), ok
+}
+
+func (uf upperFields) Texts() []string {
+	var texts []string
+	for _, text := range uf {
+		texts = append(texts, strings.ToUpper(text))
+	}
+	return texts
+}
+
+var shortPost = post{
+	Author: "kranzuft",
+	Title:  "The lazy fox jumped over the fence",
+	Tags:   []string{"animals", "fences"},
+	Secret: "password",
+	Likes:  42,
+	meta:   meta{Source: "blog"},
+}
+
+// Get rid of error, we just want to no if success or not
+func SearchRecord(command string, record interface{}) bool {
+	success, _ := stoc.SearchRecord(command, stoc.StructFields(record))
+	return success
+}
+
+/**
+ * BDD Tests
+ */
+var _ = Describe("Search records", func() {
+	Describe("bare terms", func() {
+		Context("in any text field", func() {
+			It("should be true", func() {
+				Expect(SearchRecord("kranz", shortPost)).To(Equal(true))
+				Expect(SearchRecord("lazy & fox", shortPost)).To(Equal(true))
+				Expect(SearchRecord("animals & kranz", &shortPost)).To(Equal(true))
+				Expect(SearchRecord("blog", shortPost)).To(Equal(true))
+			})
+		})
+
+		Context("in no text field", func() {
+			It("should be false", func() {
+				Expect(SearchRecord("dog", shortPost)).To(Equal(false))
+				Expect(SearchRecord("password", shortPost)).To(Equal(false))
+				Expect(SearchRecord("42", shortPost)).To(Equal(false))
+				Expect(SearchRecord("kranzuft & dog", shortPost)).To(Equal(false))
+			})
+		})
+	})
+
+	Describe("field terms", func() {
+		Context("in the named field", func() {
+			It("should be true", func() {
+				Expect(SearchRecord("author:kranz", shortPost)).To(Equal(true))
+				Expect(SearchRecord("author:\"kranz\"", shortPost)).To(Equal(true))
+				Expect(SearchRecord("Author:'kranz'", shortPost)).To(Equal(true))
+				Expect(SearchRecord("heading:lazy & tags:fences", shortPost)).To(Equal(true))
+				Expect(SearchRecord("source:blog", shortPost)).To(Equal(true))
+				Expect(SearchRecord("!heading:kranz", shortPost)).To(Equal(true))
+			})
+		})
+
+		Context("in another field", func() {
+			It("should be false", func() {
+				Expect(SearchRecord("heading:kranz", shortPost)).To(Equal(false))
+				Expect(SearchRecord("author:lazy", shortPost)).To(Equal(false))
+				Expect(SearchRecord("title:lazy", shortPost)).To(Equal(false))
+				Expect(SearchRecord("secret:password", shortPost)).To(Equal(false))
+			})
+		})
+
+		Context("with a prefix that is not a field", func() {
+			It("should search the whole term", func() {
+				Expect(SearchRecord("https://example.com", post{Title: "see https://example.com"})).To(Equal(true))
+				Expect(SearchRecord("see:lazy", shortPost)).To(Equal(false))
+			})
+		})
+	})
+
+	Describe("custom Fields implementations", func() {
+		It("should be used for lookups and bare terms", func() {
+			record := upperFields{"author": "kranzuft", "body": "hello world"}
+			Expect(stoc.SearchRecord("author:KRANZ & WORLD", record)).To(Equal(true))
+			Expect(stoc.SearchRecord("author:WORLD", record)).To(Equal(false))
+		})
+	})
+
+	Describe("filtering slices", func() {
+		It("should keep matching records in order", func() {
+			posts := []post{
+				{Author: "kranzuft", Title: "foxes"},
+				{Author: "someone", Title: "dogs"},
+				{Author: "kranzuft", Title: "dogs"},
+			}
+			prepared, err := stoc.LexIntoTokens(types.DefaultTokensDefinition, "author:kranzuft & !heading:foxes")
+			Expect(err).To(BeNil())
+			Expect(stoc.FilterStructs(prepared, posts)).To(Equal([]post{posts[2]}))
+		})
+	})
+
+	Describe("values that are not structs", func() {
+		It("should have no fields", func() {
+			Expect(SearchRecord("foo", "foo")).To(Equal(false))
+			Expect(SearchRecord("!foo", (*post)(nil))).To(Equal(true))
+		})
+	})
+})
@@ -0,0 +1,136 @@
+package com_nodlim_stoc
+
+import (
+	"errors"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"math/rand"
+	"regexp"
+)
+
+// ToRegexp converts the command, we just want the pattern
+func ToRegexp(command string) string {
+	pattern, _ := stoc.ToRegexp(LexIntoTokens(command), 0)
+	return pattern
+}
+
+// regexpTermPool adds expressions that overlap, which conjunctions cannot have, to the term pool, twice so that
+// conditions often have them
+var regexpTermPool = append([]string{"foxes", "ab", "bc", "foxes", "ab", "bc"}, termPool...)
+
+/**
+ * Regular Expression Tests
+ */
+var _ = Describe("Regular expressions of conditions", func() {
+	Describe("expressions", func() {
+		It("should be quoted", func() {
+			Expect(ToRegexp("lazy")).To(Equal("(?s)lazy"))
+			Expect(ToRegexp("'a.b*(c)'")).To(Equal(`(?s)a\.b\*\(c\)`))
+		})
+	})
+
+	Describe("disjunctions", func() {
+		It("should be alternations", func() {
+			Expect(ToRegexp("lazy | fox | dog")).To(Equal("(?s)lazy|fox|dog"))
+			Expect(ToRegexp("lazy & (fox | dog)")).To(Equal("(?s)(?:lazy.*(?:fox|dog)|(?:fox|dog).*lazy)"))
+		})
+	})
+
+	Describe("conjunctions", func() {
+		It("should be every ordering of the operands", func() {
+			Expect(ToRegexp("lazy | fox & dog")).To(Equal("(?s)(?:(?:lazy|fox).*dog|dog.*(?:lazy|fox))"))
+			Expect(ToRegexp("a & b & c")).To(Equal("(?s)(?:a.*b.*c|a.*c.*b|b.*a.*c|b.*c.*a|c.*a.*b|c.*b.*a)"))
+		})
+
+		It("should limit the number of orderings", func() {
+			_, err := stoc.ToRegexp(LexIntoTokens("a & b & c & d"), 6)
+			var limitErr *stoc.LimitError
+			Expect(errors.As(err, &limitErr)).To(BeTrue())
+			Expect(limitErr.Kind).To(Equal(stoc.PermutationsLimit))
+			Expect(limitErr.Error()).To(Equal("limit exceeded, number of permutations of 24 is over the maximum of 6"))
+
+			_, err = stoc.ToRegexp(LexIntoTokens("a & b & c & d & e & f"), 0)
+			Expect(errors.As(err, &limitErr)).To(BeTrue())
+			Expect(limitErr.Max).To(Equal(stoc.DefaultMaxPermutations))
+
+			_, err = stoc.ToRegexp(LexIntoTokens("a & b & c & d & e"), 0)
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("inversions", func() {
+		It("should exclude single characters from the whole target", func() {
+			Expect(ToRegexp("!;")).To(Equal("(?s)^[^;]*$"))
+			Expect(ToRegexp("lazy & !; & !-")).To(Equal(`(?s)^[^;\-]*lazy[^;\-]*$`))
+			Expect(ToRegexp("(a & b & !,) | c")).To(Equal("(?s)^[^,]*(?:a[^,]*b|b[^,]*a)[^,]*$|c"))
+		})
+
+		It("should never match expressions containing excluded characters", func() {
+			pattern := ToRegexp("'a;b' & !;")
+			Expect(regexp.MustCompile(pattern).MatchString("a;b")).To(BeFalse())
+			Expect(regexp.MustCompile(pattern).MatchString("")).To(BeFalse())
+		})
+
+		It("should report inversions that cannot be expressed", func() {
+			_, err := stoc.ToRegexp(LexIntoTokens("lazy & !fox"), 0)
+			Expect(errors.Is(err, stoc.ErrNotRegexp)).To(BeTrue())
+			Expect(err.Error()).To(Equal("cannot convert to a regular expression, the inversion of \"fox\" is not of a single character"))
+
+			_, err = stoc.ToRegexp(LexIntoTokens("a & (b | !;)"), 0)
+			Expect(errors.Is(err, stoc.ErrNotRegexp)).To(BeTrue())
+			Expect(err.Error()).To(Equal("cannot convert to a regular expression, the inversion of \";\" is not an operand of the whole condition"))
+		})
+	})
+
+	Describe("constants", func() {
+		It("should match every target or none", func() {
+			always := regexp.MustCompile(ToRegexp("a | !a"))
+			never := regexp.MustCompile(ToRegexp("a & !a"))
+			for _, target := range targetCorpus {
+				Expect(always.MatchString(target)).To(BeTrue())
+				Expect(never.MatchString(target)).To(BeFalse())
+			}
+		})
+	})
+
+	It("should reject invalid tokens", func() {
+		_, err := stoc.ToRegexp(stoc.PreparedTokens{{Typ: types.ANDNOT, Exp: "&!"}}, 0)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should report conjunctions of expressions that can overlap", func() {
+		for _, condition := range []string{"fox & foxes", "foxes & (cat | fox)", "ab & bc", "bc & ab", "a & !b & ab"} {
+			_, err := stoc.ToRegexp(LexIntoTokens(condition), 0)
+			Expect(errors.Is(err, stoc.ErrNotRegexp)).To(BeTrue(), condition)
+		}
+
+		_, err := stoc.ToRegexp(LexIntoTokens("ab & bc"), 0)
+		Expect(err.Error()).To(Equal("cannot convert to a regular expression, the expressions \"ab\" and \"bc\" of a conjunction can overlap"))
+		Expect(ToRegexp("ab & cd | ab & bc")).To(BeEmpty())
+		Expect(ToRegexp("ab | bc")).To(Equal("(?s)ab|bc"))
+	})
+
+	It("should match the same targets as searching", func() {
+		random := rand.New(rand.NewSource(40))
+		targets := append(randomTargets(random, 30), targetCorpus...)
+		targets = append(targets, "abc", "foxes", "bcab", "ab~bc")
+		converted := 0
+		for i := 0; i < 300; i++ {
+			tokens := randomTokensFrom(random, 3, regexpTermPool)
+			pattern, err := stoc.ToRegexp(tokens, 0)
+			if errors.Is(err, stoc.ErrNotRegexp) {
+				continue
+			}
+			Expect(err).To(BeNil())
+			converted++
+
+			compiled := regexp.MustCompile(pattern)
+			for _, target := range targets {
+				Expect(compiled.MatchString(target)).To(Equal(stoc.SearchTokens(tokens, target)), pattern)
+			}
+		}
+		Expect(converted).To(BeNumerically(">", 50))
+	})
+})
@@ -20,9 +20,13 @@ func TestSearch(t *testing.T) {
 	RunSpecs(t, "Search")
 }
 
-// Get rid of error, we just want to no if success or not
+// Get rid of error, we just want to no if success or not.
+// Every preset syntax is checked to give the same result, see expectPresetsAgree.
 func SearchString(command string, target string) bool {
-	success, _ := stoc.SearchString(command, target)
+	success, err := stoc.SearchString(command, target)
+	if err == nil {
+		expectPresetsAgree(command, target, success)
+	}
 	return success
 }
 
@@ -0,0 +1,149 @@
+package com_nodlim_stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"math/rand"
+)
+
+// Simplify simplifies the command and formats the result, we just want the condition
+func Simplify(command string) string {
+	simplified, _ := stoc.Simplify(LexIntoTokens(command))
+	condition, _ := stoc.Format(types.DefaultTokensDefinition, simplified)
+	return condition
+}
+
+// Minimise minimises the command and formats the result, we just want the condition
+func Minimise(command string) string {
+	minimised, _ := stoc.Minimise(LexIntoTokens(command))
+	condition, _ := stoc.Format(types.DefaultTokensDefinition, minimised)
+	return condition
+}
+
+/**
+ * BDD Tests
+ */
+var _ = Describe("Simplify conditions", func() {
+	Describe("double inversion", func() {
+		It("should be removed", func() {
+			Expect(Simplify("!(!a)")).To(Equal("a"))
+			Expect(Simplify("b & !(!a)")).To(Equal("b & a"))
+			Expect(Simplify("!(!(!a))")).To(Equal("!a"))
+		})
+	})
+
+	Describe("idempotence", func() {
+		It("should remove repeated operands", func() {
+			Expect(Simplify("a & a")).To(Equal("a"))
+			Expect(Simplify("a | b | a | 'b'")).To(Equal("a | b"))
+			Expect(Simplify("(a & b) | (b & a)")).To(Equal("a & b"))
+		})
+	})
+
+	Describe("absorption", func() {
+		It("should remove absorbed operands", func() {
+			Expect(Simplify("a & (a | b)")).To(Equal("a"))
+			Expect(Simplify("a | (a & b)")).To(Equal("a"))
+			Expect(Simplify("(a | b) & (c | a | b)")).To(Equal("a | b"))
+		})
+	})
+
+	Describe("complements", func() {
+		It("should fold to constants", func() {
+			Expect(Simplify("a & !a")).To(Equal("!\"\""))
+			Expect(Simplify("a | !a")).To(Equal("\"\""))
+			Expect(Simplify("b | (a & !a)")).To(Equal("b"))
+			Expect(Simplify("b & (a | !a)")).To(Equal("b"))
+		})
+	})
+
+	Describe("constant folding", func() {
+		It("should remove types.TRUE", func() {
+			simplified, err := stoc.Simplify(stoc.PreparedTokens{{Typ: types.TRUE}, {Typ: types.EXP, Exp: "a"}, {Typ: types.AND}})
+			Expect(err).To(BeNil())
+			Expect(simplified).To(Equal(LexIntoTokens("a")))
+
+			simplified, err = stoc.Simplify(stoc.PreparedTokens{{Typ: types.TRUE}, {Typ: types.EXP, Exp: "a"}, {Typ: types.OR}})
+			Expect(err).To(BeNil())
+			Expect(stoc.SearchTokens(simplified, "")).To(Equal(true))
+			Expect(simplified).To(HaveLen(1))
+		})
+	})
+
+	Describe("the condition corpus", func() {
+		It("should mean the same and be no larger", func() {
+			for _, condition := range conditionCorpus {
+				prepared := LexIntoTokens(condition)
+				simplified, err := stoc.Simplify(prepared)
+				Expect(err).To(BeNil())
+				minimised, err := stoc.Minimise(prepared)
+				Expect(err).To(BeNil())
+
+				Expect(len(simplified)).To(BeNumerically("<=", len(prepared)), condition)
+				Expect(len(minimised)).To(BeNumerically("<=", len(simplified)), condition)
+				for _, target := range targetCorpus {
+					Expect(stoc.SearchTokens(simplified, target)).To(Equal(stoc.SearchTokens(prepared, target)), condition)
+					Expect(stoc.SearchTokens(minimised, target)).To(Equal(stoc.SearchTokens(prepared, target)), condition)
+				}
+			}
+		})
+	})
+
+	Describe("random conditions", func() {
+		It("should mean the same", func() {
+			rnd := rand.New(rand.NewSource(35))
+			targets := randomTargets(rnd, 64)
+			for i := 0; i < 300; i++ {
+				prepared := randomTokens(rnd, 5)
+				simplified, err := stoc.Simplify(prepared)
+				Expect(err).To(BeNil())
+				minimised, err := stoc.Minimise(prepared)
+				Expect(err).To(BeNil())
+				for _, target := range targets {
+					Expect(stoc.SearchTokens(simplified, target)).To(Equal(stoc.SearchTokens(prepared, target)))
+					Expect(stoc.SearchTokens(minimised, target)).To(Equal(stoc.SearchTokens(prepared, target)))
+				}
+			}
+		})
+	})
+
+	Describe("invalid tokens", func() {
+		It("should return an error", func() {
+			_, err := stoc.Simplify(stoc.PreparedTokens{{Typ: types.OR}})
+			Expect(err).NotTo(BeNil())
+			_, err = stoc.Minimise(nil)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
+
+var _ = Describe("Minimise conditions", func() {
+	Describe("complex example", func() {
+		It("should reduce to its essence", func() {
+			Expect(Minimise(conditionCorpus[0])).To(Equal("dog | !lazy"))
+		})
+	})
+
+	Describe("redundant disjunction", func() {
+		It("should find the minimal form", func() {
+			Expect(Minimise("(a & b) | (a & !b)")).To(Equal("a"))
+			Expect(Minimise("(a & b) | (!a & c) | (b & c)")).To(Equal("a & b | (c & !a)"))
+			Expect(Minimise("(a | b) & (a | !b)")).To(Equal("a"))
+		})
+	})
+
+	Describe("conjunctive form", func() {
+		It("should be used when smaller", func() {
+			Expect(Minimise("(a & c) | (a & d) | (b & c) | (b & d)")).To(Equal("c | d & (a | b)"))
+		})
+	})
+
+	Describe("many distinct expressions", func() {
+		It("should only be simplified", func() {
+			condition := "a | b | c | d | e | f | g | h | (h & i)"
+			Expect(Minimise(condition)).To(Equal("a | b | c | d | e | f | g | h"))
+		})
+	})
+})
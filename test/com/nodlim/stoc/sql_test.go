@@ -0,0 +1,150 @@
+package com_nodlim_stoc
+
+import (
+	"database/sql"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"math/rand"
+	_ "modernc.org/sqlite"
+)
+
+// ToSQL renders the command, we just want the clause and its parameters
+func ToSQL(command string, options stoc.SQLOptions) (string, []any) {
+	clause, args, _ := stoc.ToSQL(LexIntoTokens(command), options)
+	return clause, args
+}
+
+// sqlSearch returns whether each target is met by the clause, using an in-memory SQLite database
+func sqlSearch(db *sql.DB, clause string, args []any, targets []string) []bool {
+	matched := make([]bool, len(targets))
+	rows, err := db.Query("SELECT id FROM documents WHERE "+clause, args...)
+	Expect(err).To(BeNil(), clause)
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		Expect(rows.Scan(&id)).To(Succeed())
+		matched[id] = true
+	}
+	Expect(rows.Err()).To(BeNil())
+	return matched
+}
+
+/**
+ * SQL Tests
+ */
+var _ = Describe("SQL WHERE clauses of conditions", func() {
+	instr := stoc.SQLOptions{Column: "body", Match: stoc.InstrMatch}
+
+	Describe("rendering", func() {
+		It("should render expressions as parameters", func() {
+			clause, args := ToSQL("lazy & !(fox | dog)", instr)
+			Expect(clause).To(Equal("instr(body, ?) > 0 AND NOT (instr(body, ?) > 0 OR instr(body, ?) > 0)"))
+			Expect(args).To(Equal([]any{"lazy", "fox", "dog"}))
+
+			clause, _ = ToSQL("a | b & c", instr)
+			Expect(clause).To(Equal("(instr(body, ?) > 0 OR instr(body, ?) > 0) AND instr(body, ?) > 0"))
+		})
+
+		It("should render each kind of match", func() {
+			clause, args := ToSQL("lazy", stoc.SQLOptions{Column: "body"})
+			Expect(clause).To(Equal(`body LIKE ? ESCAPE '\'`))
+			Expect(args).To(Equal([]any{"%lazy%"}))
+
+			clause, _ = ToSQL("lazy", stoc.SQLOptions{Column: "body", Match: stoc.ILikeMatch})
+			Expect(clause).To(Equal(`body ILIKE ? ESCAPE '\'`))
+
+			clause, args = ToSQL("lazy", stoc.SQLOptions{Column: "body", Match: stoc.StrposMatch})
+			Expect(clause).To(Equal("strpos(body, ?) > 0"))
+			Expect(args).To(Equal([]any{"lazy"}))
+		})
+
+		It("should escape wildcards of LIKE", func() {
+			_, args := ToSQL(`'100%' | a_b | 'c\d'`, stoc.SQLOptions{Column: "body"})
+			Expect(args).To(Equal([]any{`%100\%%`, `%a\_b%`, `%c\\d%`}))
+		})
+
+		It("should number placeholders", func() {
+			clause, _ := ToSQL("a &! b", stoc.SQLOptions{Column: "body", Match: stoc.StrposMatch, Placeholder: stoc.DollarPlaceholder})
+			Expect(clause).To(Equal("strpos(body, $1) > 0 AND NOT (strpos(body, $2) > 0)"))
+		})
+
+		It("should render constants", func() {
+			clause, _ := ToSQL("!(!lazy)", instr)
+			Expect(clause).To(Equal("instr(body, ?) > 0"))
+			clause, _ = ToSQL("!a", instr)
+			Expect(clause).To(Equal("NOT (instr(body, ?) > 0)"))
+		})
+
+		It("should require a column", func() {
+			_, _, err := stoc.ToSQL(LexIntoTokens("a"), stoc.SQLOptions{})
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Describe("SQLite", func() {
+		var db *sql.DB
+		targets := append(append([]string{"100%", "100 percent", "a_b", "axb", `c\d`}, targetCorpus...),
+			randomTargets(rand.New(rand.NewSource(41)), 30)...)
+
+		BeforeEach(func() {
+			var err error
+			db, err = sql.Open("sqlite", ":memory:")
+			Expect(err).To(BeNil())
+			// every connection to :memory: is a new database
+			db.SetMaxOpenConns(1)
+			_, err = db.Exec("CREATE TABLE documents (id INTEGER PRIMARY KEY, body TEXT NOT NULL)")
+			Expect(err).To(BeNil())
+			for id, target := range targets {
+				_, err = db.Exec("INSERT INTO documents (id, body) VALUES (?, ?)", id, target)
+				Expect(err).To(BeNil())
+			}
+		})
+
+		AfterEach(func() {
+			Expect(db.Close()).To(Succeed())
+		})
+
+		expectSameAsSearching := func(options stoc.SQLOptions, tokens stoc.PreparedTokens) {
+			clause, args, err := stoc.ToSQL(tokens, options)
+			Expect(err).To(BeNil())
+
+			matched := sqlSearch(db, clause, args, targets)
+			for id, target := range targets {
+				Expect(matched[id]).To(Equal(stoc.SearchTokens(tokens, target)), clause)
+			}
+		}
+
+		It("should match the same rows as searching with instr", func() {
+			for _, command := range append(conditionCorpus, `'100%'`, "a_b", `'c\d'`) {
+				expectSameAsSearching(instr, LexIntoTokens(command))
+			}
+
+			random := rand.New(rand.NewSource(41))
+			for i := 0; i < 100; i++ {
+				expectSameAsSearching(instr, randomTokens(random, 3))
+			}
+		})
+
+		It("should match the same rows as searching with case-sensitive LIKE", func() {
+			_, err := db.Exec("PRAGMA case_sensitive_like = ON")
+			Expect(err).To(BeNil())
+
+			like := stoc.SQLOptions{Column: "body"}
+			for _, command := range append(conditionCorpus, `'100%'`, "a_b", `'c\d'`) {
+				expectSameAsSearching(like, LexIntoTokens(command))
+			}
+
+			random := rand.New(rand.NewSource(41))
+			for i := 0; i < 100; i++ {
+				expectSameAsSearching(like, randomTokens(random, 3))
+			}
+		})
+
+		It("should not treat wildcards as wildcards", func() {
+			clause, args := ToSQL("'100%' | a_b", stoc.SQLOptions{Column: "body"})
+			matched := sqlSearch(db, clause, args, targets)
+			Expect(matched[:5]).To(Equal([]bool{true, false, true, false, false}))
+		})
+	})
+})
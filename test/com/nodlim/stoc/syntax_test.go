@@ -0,0 +1,199 @@
+package com_nodlim_stoc
+
+import (
+	"errors"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	stoctypes "github.com/kranzuft/stoc/cmd/com/nodlim/stoc/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
+)
+
+// syntaxYAML is a YAML configuration with the given tokens, for testing validation
+func syntaxYAML(tokens string) []byte {
+	return []byte("version: 1\ntokens:\n" + tokens)
+}
+
+// requiredYAML are the tokens of the words syntax that every configuration needs
+const requiredYAML = "  AND: and\n  OR: or\n  NOT: not\n  LEFT_BRACKET: '{'\n  RIGHT_BRACKET: '}'\n" +
+	"  DOUBLE_INVERTED_COMMA: '\"'\n  SINGLE_INVERTED_COMMA: \"'\"\n"
+
+/**
+ * Syntax Configuration Tests
+ */
+var _ = Describe("Syntax configuration", func() {
+	Describe("loading", func() {
+		It("should load YAML", func() {
+			defs, err := stoc.LoadTokensDefinition(filepath.Join("testdata", "syntax", "words.yaml"))
+			Expect(err).To(BeNil())
+			Expect(defs).To(Equal(wordsTokensDefinition))
+		})
+
+		It("should load JSON", func() {
+			defs, err := stoc.LoadTokensDefinition(filepath.Join("testdata", "syntax", "words.json"))
+			Expect(err).To(BeNil())
+			Expect(defs.TokToString(types.AND)).To(Equal("conjunction"))
+			Expect(defs.TokToString(types.OR)).To(Equal("or"))
+
+			Expect(stoc.SearchStringCustom(defs, "lazy and not {dog or cat}", shortTargetProse)).To(BeTrue())
+			Expect(stoc.SearchStringCustom(defs, "lazy and 'dog or cat'", shortTargetProse)).To(BeFalse())
+		})
+
+		It("should report missing files and unknown formats", func() {
+			_, err := stoc.LoadTokensDefinition(filepath.Join("testdata", "syntax", "missing.yaml"))
+			Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+
+			_, err = stoc.LoadTokensDefinition(filepath.Join("testdata", "syntax", "words.toml"))
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal("unknown syntax configuration format \".toml\", expected .json, .yaml or .yml"))
+		})
+	})
+
+	Describe("saving", func() {
+		It("should save and load the same definition", func() {
+			dir, err := os.MkdirTemp("", "stoc-syntax")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(dir)
+
+			for _, name := range []string{"syntax.json", "syntax.yaml", "syntax.yml"} {
+				for _, defs := range []types.TokensDefinition{types.DefaultTokensDefinition, wordsTokensDefinition} {
+					path := filepath.Join(dir, name)
+					Expect(stoc.SaveTokensDefinition(path, defs)).To(Succeed())
+					loaded, err := stoc.LoadTokensDefinition(path)
+					Expect(err).To(BeNil())
+					// loaded records its keys, see stoctypes.Definition, so it is compared by its configuration
+					expected, _ := stoc.MarshalTokensDefinitionJSON(defs)
+					Expect(stoc.MarshalTokensDefinitionJSON(loaded)).To(Equal(expected))
+				}
+			}
+		})
+
+		It("should encode descriptions only when they differ from the default", func() {
+			data, err := stoc.MarshalTokensDefinitionJSON(types.DefaultTokensDefinition)
+			Expect(err).To(BeNil())
+			Expect(string(data)).NotTo(ContainSubstring("descriptions"))
+
+			def := stoctypes.Definition{}
+			defs := def.DefineTokenInfo(types.AND, "+", "plus").
+				DefineTokenInfo(types.OR, ",", "or").
+				DefineTokenInfo(types.NOT, "-", "not").
+				DefineTokenInfo(types.LBR, "[", "left bracket").
+				DefineTokenInfo(types.RBR, "]", "right bracket").
+				DefineTokenInfo(types.DQUOTE, "\"", "double inverted comma").
+				DefineTokenInfo(types.SQUOTE, "`", "single inverted comma").
+				Finalise()
+			data, err = stoc.MarshalTokensDefinitionYAML(defs)
+			Expect(err).To(BeNil())
+			Expect(string(data)).To(Equal("version: 1\ntokens:\n  AND: +\n  DOUBLE_INVERTED_COMMA: '\"'\n" +
+				"  LEFT_BRACKET: '['\n  NOT: '-'\n  OR: ','\n  RIGHT_BRACKET: ']'\n  SINGLE_INVERTED_COMMA: '`'\n" +
+				"descriptions:\n  AND: plus\n"))
+		})
+
+		It("should not save invalid definitions", func() {
+			dir, err := os.MkdirTemp("", "stoc-syntax")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "syntax.yaml")
+			Expect(stoc.SaveTokensDefinition(path, types.TokensDefinition{})).NotTo(Succeed())
+			_, err = os.Stat(path)
+			Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+		})
+	})
+
+	Describe("validation", func() {
+		expectInvalid := func(data []byte, message string) {
+			_, err := stoc.UnmarshalTokensDefinitionYAML(data)
+			Expect(errors.Is(err, stoc.ErrInvalidSyntax)).To(BeTrue(), string(data))
+			Expect(err.Error()).To(Equal(message))
+		}
+
+		It("should accept the built in and test syntaxes", func() {
+			Expect(stoc.ValidateTokensDefinition(types.DefaultTokensDefinition)).To(Succeed())
+			Expect(stoc.ValidateTokensDefinition(wordsTokensDefinition)).To(Succeed())
+			_, err := stoc.UnmarshalTokensDefinitionYAML(syntaxYAML(requiredYAML))
+			Expect(err).To(BeNil())
+		})
+
+		It("should reject missing token types", func() {
+			expectInvalid(syntaxYAML("  AND: and\n"), "invalid syntax, missing key for OR")
+			expectInvalid(syntaxYAML("  AND: and\n  OR: or\n  NOT: not\n  LEFT_BRACKET: '{'\n  RIGHT_BRACKET: '}'\n"+
+				"  DOUBLE_INVERTED_COMMA: '\"'\n"), "invalid syntax, missing key for SINGLE_INVERTED_COMMA")
+		})
+
+		It("should reject keys that are not known", func() {
+			// the keys of a syntax defined with the lexer alone are not recorded
+			defs := types.TokensDefinition{}
+			defs.DefineTokenInfo(types.AND, "and", "and").
+				DefineTokenInfo(types.OR, "or", "or").
+				DefineTokenInfo(types.NOT, "not", "not").
+				DefineTokenInfo(types.LBR, "{", "left bracket").
+				DefineTokenInfo(types.RBR, "}", "right bracket").
+				DefineTokenInfo(types.DQUOTE, "\"", "double inverted comma").
+				DefineTokenInfo(types.SQUOTE, "'", "single inverted comma")
+			err := stoc.ValidateTokensDefinition(defs)
+			Expect(errors.Is(err, stoc.ErrInvalidSyntax)).To(BeTrue())
+			Expect(err.Error()).To(Equal("invalid syntax, unknown key for AND, define the syntax with a types.Definition"))
+		})
+
+		It("should know the keys of a syntax defined with a definition", func() {
+			key, ok := stoctypes.Key(wordsTokensDefinition, types.ANDNOT)
+			Expect(key).To(Equal("and not"))
+			Expect(ok).To(BeTrue())
+			key, ok = stoctypes.Key(types.DefaultTokensDefinition, types.LBR)
+			Expect(key).To(Equal("("))
+			Expect(ok).To(BeTrue())
+			_, ok = stoctypes.Key(wordsTokensDefinition, stoctypes.COMMENT)
+			Expect(ok).To(BeFalse())
+
+			// a key defined again without the definition is no longer known
+			def := stoctypes.Definition{}
+			defs := def.DefineTokenInfo(types.AND, "and", "and").Finalise()
+			defs.DefineTokenInfo(types.AND, "und", "and")
+			_, ok = stoctypes.Key(defs, types.AND)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should reject empty keys", func() {
+			expectInvalid(syntaxYAML("  AND: and\n  OR: or\n  NOT: ''\n"), "invalid syntax, missing key for NOT")
+		})
+
+		It("should reject duplicate keys", func() {
+			expectInvalid(syntaxYAML("  AND: and\n  OR: and\n  NOT: not\n  LEFT_BRACKET: '{'\n  RIGHT_BRACKET: '}'\n"+
+				"  DOUBLE_INVERTED_COMMA: '\"'\n  SINGLE_INVERTED_COMMA: \"'\"\n"),
+				"invalid syntax, duplicate key \"and\" for AND and OR")
+		})
+
+		It("should reject ambiguous prefixes", func() {
+			expectInvalid(syntaxYAML("  AND: and\n  OR: or\n  NOT: andnot\n  LEFT_BRACKET: '{'\n  RIGHT_BRACKET: '}'\n"+
+				"  DOUBLE_INVERTED_COMMA: '\"'\n  SINGLE_INVERTED_COMMA: \"'\"\n"),
+				"invalid syntax, ambiguous keys, \"and\" of AND is a prefix of \"andnot\" of NOT")
+			expectInvalid(syntaxYAML("  AND: and\n  OR: or\n  NOT: not\n  LEFT_BRACKET: '{{'\n  RIGHT_BRACKET: '}'\n"+
+				"  DOUBLE_INVERTED_COMMA: '{'\n  SINGLE_INVERTED_COMMA: \"'\"\n"),
+				"invalid syntax, ambiguous keys, \"{\" of DOUBLE_INVERTED_COMMA is a prefix of \"{{\" of LEFT_BRACKET")
+		})
+
+		It("should reject keys with surrounding whitespace", func() {
+			expectInvalid(syntaxYAML("  AND: ' and '\n  OR: or\n  NOT: not\n  LEFT_BRACKET: '{'\n  RIGHT_BRACKET: '}'\n"+
+				"  DOUBLE_INVERTED_COMMA: '\"'\n  SINGLE_INVERTED_COMMA: \"'\"\n"),
+				"invalid syntax, key \" and \" of AND starts or ends with whitespace")
+		})
+
+		It("should reject unknown token types and versions", func() {
+			expectInvalid(syntaxYAML(requiredYAML+"  XOR: xor\n  MAYBE: maybe\n"), "invalid syntax, unknown token type \"MAYBE\"")
+			expectInvalid([]byte("version: 2\ntokens:\n"+requiredYAML), "invalid syntax, unsupported version 2")
+			expectInvalid([]byte("tokens:\n"+requiredYAML), "invalid syntax, unsupported version 0")
+			expectInvalid([]byte("version: 1\ntokens:\n"+requiredYAML+"descriptions:\n  TRUE: yes\n"),
+				"invalid syntax, description of TRUE which has no key")
+		})
+
+		It("should reject unknown fields of YAML and malformed files", func() {
+			_, err := stoc.UnmarshalTokensDefinitionYAML([]byte("version: 1\nkeywords:\n" + requiredYAML))
+			Expect(err).NotTo(BeNil())
+			_, err = stoc.UnmarshalTokensDefinitionJSON([]byte("{"))
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
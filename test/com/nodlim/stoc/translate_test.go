@@ -0,0 +1,73 @@
+package com_nodlim_stoc
+
+import (
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/pos_error"
+	"github.com/kranzuft/boolean-algebra-to-tokens/cmd/com/nodlim/batt/types"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// Get rid of error, we just want the condition
+func Translate(fromDefs types.TokensDefinition, toDefs types.TokensDefinition, condition string) string {
+	translated, _ := stoc.Translate(fromDefs, toDefs, condition)
+	return translated
+}
+
+/**
+ * BDD Tests
+ */
+var _ = Describe("Translate between syntaxes", func() {
+	Describe("symbols to words", func() {
+		It("should use the word keywords", func() {
+			Expect(Translate(types.DefaultTokensDefinition, wordsTokensDefinition, "(dog | cat) & !'sandwich'")).
+				To(Equal("dog or cat and not \"sandwich\""))
+			Expect(Translate(types.DefaultTokensDefinition, wordsTokensDefinition, "lazy &! fox |! (the & fence)")).
+				To(Equal("lazy and not fox or not {the and fence}"))
+		})
+
+		It("should keep brackets that change the meaning", func() {
+			Expect(Translate(types.DefaultTokensDefinition, wordsTokensDefinition, "dog | (cat & !'sandwich')")).
+				To(Equal("dog or {cat and not \"sandwich\"}"))
+		})
+
+		It("should quote expressions that are keywords of the word syntax", func() {
+			Expect(Translate(types.DefaultTokensDefinition, wordsTokensDefinition, "or | not & {x}")).
+				To(Equal("\"or\" or \"not\" and \"{x}\""))
+		})
+	})
+
+	Describe("words to symbols", func() {
+		It("should use the symbol keywords", func() {
+			Expect(Translate(wordsTokensDefinition, types.DefaultTokensDefinition, "{dog or cat} and not 'sandwich'")).
+				To(Equal("dog | cat & !sandwich"))
+		})
+
+		It("should quote expressions that are keywords of the symbol syntax", func() {
+			Expect(Translate(wordsTokensDefinition, types.DefaultTokensDefinition, "rock & roll or (maybe)")).
+				To(Equal("\"rock & roll\" | \"(maybe)\""))
+		})
+	})
+
+	Describe("round trip", func() {
+		It("should mean the same after translating to words and back", func() {
+			for _, condition := range conditionCorpus {
+				words, err := stoc.Translate(types.DefaultTokensDefinition, wordsTokensDefinition, condition)
+				Expect(err).To(BeNil())
+				symbols, err := stoc.Translate(wordsTokensDefinition, types.DefaultTokensDefinition, words)
+				Expect(err).To(BeNil())
+				for _, target := range targetCorpus {
+					Expect(SearchString(symbols, target)).To(Equal(SearchString(condition, target)), words)
+				}
+			}
+		})
+	})
+
+	Describe("invalid condition", func() {
+		It("should return the lexing error", func() {
+			_, err := stoc.Translate(types.DefaultTokensDefinition, wordsTokensDefinition, "foo &")
+			_, isPosErr := err.(pos_error.PosError)
+			Expect(isPosErr).To(Equal(true))
+		})
+	})
+})
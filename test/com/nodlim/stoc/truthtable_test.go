@@ -0,0 +1,162 @@
+package com_nodlim_stoc
+
+import (
+	"errors"
+	"github.com/kranzuft/stoc/cmd/com/nodlim/stoc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+// TruthTable enumerates the command, we just want the table
+func TruthTable(command string) *stoc.Table {
+	table, _ := stoc.TruthTable(LexIntoTokens(command))
+	return table
+}
+
+// Results are the results of each row of the table, from every expression found to none found
+func Results(table *stoc.Table) []bool {
+	results := make([]bool, len(table.Rows))
+	for i, row := range table.Rows {
+		results[i] = row.Result
+	}
+	return results
+}
+
+// overlapping returns true if any of the terms contains another, so they are not independent when searching
+func overlapping(terms []string) bool {
+	for i, a := range terms {
+		for j, b := range terms {
+			if i != j && strings.Contains(a, b) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/**
+ * Truth Table Tests
+ */
+var _ = Describe("Truth tables of conditions", func() {
+	Describe("truth tables of the search tests", func() {
+		It("should match (A)", func() {
+			Expect(Results(TruthTable("A"))).To(Equal([]bool{true, false}))
+		})
+
+		It("should match (A̅)", func() {
+			Expect(Results(TruthTable("!A"))).To(Equal([]bool{false, true}))
+		})
+
+		It("should match (A+B)", func() {
+			Expect(Results(TruthTable("A | B"))).To(Equal([]bool{true, true, true, false}))
+		})
+
+		It("should match (A.B)", func() {
+			Expect(Results(TruthTable("A & B"))).To(Equal([]bool{true, false, false, false}))
+		})
+
+		It("should match (A.B̅)", func() {
+			Expect(Results(TruthTable("A &! B"))).To(Equal([]bool{false, true, false, false}))
+		})
+
+		It("should match (A+B̅)", func() {
+			Expect(Results(TruthTable("A |! B"))).To(Equal([]bool{true, true, false, true}))
+		})
+
+		It("should match A+(B+C)", func() {
+			Expect(Results(TruthTable("A | (B | C)"))).To(Equal([]bool{true, true, true, true, true, true, true, false}))
+		})
+
+		It("should match the complex example", func() {
+			table := TruthTable("!(((lazy & !dog))) | ((((lazy & dog)))) | !((((lazy | dog)))) | ((((!lazy & dog))))")
+			Expect(table.Terms).To(Equal([]string{"lazy", "dog"}))
+			Expect(Results(table)).To(Equal([]bool{true, false, true, true}))
+		})
+	})
+
+	It("should enumerate rows from every expression found to none found", func() {
+		table := TruthTable("a & (b | a)")
+		Expect(table.Terms).To(Equal([]string{"a", "b"}))
+		Expect(table.Rows).To(Equal([]stoc.Row{
+			{Found: []bool{true, true}, Result: true},
+			{Found: []bool{true, false}, Result: true},
+			{Found: []bool{false, true}, Result: false},
+			{Found: []bool{false, false}, Result: false},
+		}))
+	})
+
+	It("should leave out the empty expression", func() {
+		table := TruthTable("a | !''")
+		Expect(table.Terms).To(Equal([]string{"a"}))
+		Expect(Results(table)).To(Equal([]bool{true, false}))
+	})
+
+	It("should agree with searching", func() {
+		for _, command := range conditionCorpus {
+			table := TruthTable(command)
+			if overlapping(table.Terms) {
+				continue
+			}
+
+			for _, row := range table.Rows {
+				var found []string
+				for i, term := range table.Terms {
+					if row.Found[i] {
+						found = append(found, term)
+					}
+				}
+				Expect(SearchString(command, strings.Join(found, "\n"))).To(Equal(row.Result), command)
+			}
+		}
+	})
+
+	Describe("output", func() {
+		table := TruthTable("lazy |! \"big dog\"")
+
+		It("should render as text", func() {
+			Expect(table.String()).To(Equal("" +
+				"\"lazy\" \"big dog\" | result\n" +
+				"true   true      | true\n" +
+				"true   false     | true\n" +
+				"false  true      | false\n" +
+				"false  false     | true\n"))
+		})
+
+		It("should render as markdown", func() {
+			Expect(table.Markdown()).To(Equal("" +
+				"| ` lazy ` | ` big dog ` | result |\n" +
+				"| --- | --- | --- |\n" +
+				"| true | true | true |\n" +
+				"| true | false | true |\n" +
+				"| false | true | false |\n" +
+				"| false | false | true |\n"))
+		})
+
+		It("should escape markdown", func() {
+			Expect(TruthTable("'a | `b`'").Markdown()).To(HavePrefix("| `` a \\| `b` `` | result |\n"))
+		})
+
+		It("should render as CSV", func() {
+			Expect(table.CSV()).To(Equal("" +
+				"lazy,big dog,result\n" +
+				"true,true,true\n" +
+				"true,false,true\n" +
+				"false,true,false\n" +
+				"false,false,true\n"))
+			Expect(TruthTable("'a, \"b\"'").CSV()).To(HavePrefix("\"a, \"\"b\"\"\",result\n"))
+		})
+	})
+
+	It("should limit the number of expressions", func() {
+		_, err := stoc.TruthTable(LexIntoTokens("a|b|c|d|e|f|g|h|i|j|k|l|m|n|o|p|q"))
+		var limitErr *stoc.LimitError
+		Expect(errors.As(err, &limitErr)).To(BeTrue())
+		Expect(limitErr.Kind).To(Equal(stoc.TermsLimit))
+		Expect(limitErr.Actual).To(Equal(17))
+
+		table, err := stoc.TruthTable(LexIntoTokens("a|b|c|d|e|f|g|h|i|j|k|l|m|n|o|p"))
+		Expect(err).To(BeNil())
+		Expect(table.Rows).To(HaveLen(1 << 16))
+	})
+})